@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+// DefaultMaxBodyBytes is the request body size limit applied by Bind when no
+// WithMaxBodyBytes option is given.
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// BindError describes why a request body could not be decoded.
+// Status is the HTTP status code that should be returned to the client.
+type BindError struct {
+	Status int
+	Msg    string
+	Err    error
+}
+
+// Error returns the client-facing message.
+func (e *BindError) Error() string {
+	return e.Msg
+}
+
+// StatusCode returns the HTTP status code of the error, so an ErrorHandler can
+// return a BindError unchanged and have the router report the right status.
+func (e *BindError) StatusCode() int {
+	return e.Status
+}
+
+// Unwrap returns the underlying decode error, if any.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+type bindConfig struct {
+	maxBytes        int64
+	disallowUnknown bool
+}
+
+// BindOption configures how Bind decodes a request body.
+type BindOption func(c *bindConfig)
+
+// WithMaxBodyBytes limits the request body to n bytes.
+// Larger bodies fail with a 413 Request Entity Too Large BindError.
+func WithMaxBodyBytes(n int64) BindOption {
+	return func(c *bindConfig) {
+		c.maxBytes = n
+	}
+}
+
+// WithDisallowUnknownFields rejects bodies containing object keys that
+// do not match any field of the destination type.
+func WithDisallowUnknownFields() BindOption {
+	return func(c *bindConfig) {
+		c.disallowUnknown = true
+	}
+}
+
+// Bind decodes the JSON request body into a value of type T.
+// The request must declare a JSON Content-Type ("application/json" or a "+json" type),
+// the body must hold exactly one JSON value, and it may not exceed the size limit.
+// Failures are reported as a *BindError carrying a 400, 413 or 415 status.
+func Bind[T any](req *http.Request, opts ...BindOption) (T, error) {
+	var v T
+
+	cfg := bindConfig{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := checkContentType(req.Header.Get("Content-Type")); err != nil {
+		return v, err
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return v, &BindError{Status: http.StatusBadRequest, Msg: "request body is empty"}
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, req.Body, cfg.maxBytes))
+	if cfg.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(&v); err != nil {
+		return v, decodeError(err)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return v, decodeError(err)
+		}
+		return v, &BindError{Status: http.StatusBadRequest, Msg: "request body must contain a single JSON value"}
+	}
+
+	return v, nil
+}
+
+// JSON adapts a handler that receives a decoded request body of type T into a types.Handler.
+// The body is decoded with Bind; if decoding fails, the handler is not called and a JSON
+// error response with the BindError's status is returned instead.
+func JSON[T any](fn func(req *http.Request, body T) types.Responder, opts ...BindOption) types.Handler {
+	return func(req *http.Request) types.Responder {
+		body, err := Bind[T](req, opts...)
+		if err != nil {
+			var be *BindError
+			if errors.As(err, &be) {
+				return responders.JSONErrorResponse(be.Msg, be.Status)
+			}
+			return responders.JSONErrorResponse(err.Error(), http.StatusBadRequest)
+		}
+		return fn(req, body)
+	}
+}
+
+func checkContentType(ct string) error {
+	if ct == "" {
+		return &BindError{Status: http.StatusUnsupportedMediaType, Msg: "Content-Type must be application/json"}
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) {
+		return &BindError{Status: http.StatusUnsupportedMediaType, Msg: "Content-Type must be application/json", Err: err}
+	}
+	return nil
+}
+
+func decodeError(err error) *BindError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &maxErr):
+		return &BindError{
+			Status: http.StatusRequestEntityTooLarge,
+			Msg:    fmt.Sprintf("request body must not exceed %d bytes", maxErr.Limit),
+			Err:    err,
+		}
+	case errors.As(err, &syntaxErr):
+		return &BindError{
+			Status: http.StatusBadRequest,
+			Msg:    fmt.Sprintf("request body contains malformed JSON at offset %d", syntaxErr.Offset),
+			Err:    err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{Status: http.StatusBadRequest, Msg: "request body contains malformed JSON", Err: err}
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return &BindError{
+				Status: http.StatusBadRequest,
+				Msg:    fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type),
+				Err:    err,
+			}
+		}
+		return &BindError{
+			Status: http.StatusBadRequest,
+			Msg:    fmt.Sprintf("request body must be of type %s", typeErr.Type),
+			Err:    err,
+		}
+	case errors.Is(err, io.EOF):
+		return &BindError{Status: http.StatusBadRequest, Msg: "request body is empty", Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return &BindError{
+			Status: http.StatusBadRequest,
+			Msg:    "request body contains unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "),
+			Err:    err,
+		}
+	}
+
+	return &BindError{Status: http.StatusBadRequest, Msg: "request body is invalid", Err: err}
+}
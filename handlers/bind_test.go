@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/handlers"
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+type createUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name       string
+		ct         string
+		body       string
+		opts       []handlers.BindOption
+		want       createUser
+		wantStatus int
+	}{
+		{name: "valid body", ct: "application/json", body: `{"name":"alice","age":30}`, want: createUser{Name: "alice", Age: 30}},
+		{name: "charset parameter", ct: "application/json; charset=utf-8", body: `{"name":"bob"}`, want: createUser{Name: "bob"}},
+		{name: "json suffix type", ct: "application/merge-patch+json", body: `{"age":5}`, want: createUser{Age: 5}},
+		{name: "unknown fields ignored by default", ct: "application/json", body: `{"name":"carol","admin":true}`, want: createUser{Name: "carol"}},
+		{name: "missing content type", ct: "", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "wrong content type", ct: "text/plain", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "empty body", ct: "application/json", body: ``, wantStatus: http.StatusBadRequest},
+		{name: "malformed json", ct: "application/json", body: `{"name":}`, wantStatus: http.StatusBadRequest},
+		{name: "truncated json", ct: "application/json", body: `{"name":"alice"`, wantStatus: http.StatusBadRequest},
+		{name: "wrong field type", ct: "application/json", body: `{"age":"old"}`, wantStatus: http.StatusBadRequest},
+		{name: "multiple values", ct: "application/json", body: `{"name":"a"}{"name":"b"}`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "unknown fields rejected",
+			ct:         "application/json",
+			body:       `{"name":"carol","admin":true}`,
+			opts:       []handlers.BindOption{handlers.WithDisallowUnknownFields()},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "body too large",
+			ct:         "application/json",
+			body:       `{"name":"` + strings.Repeat("x", 64) + `"}`,
+			opts:       []handlers.BindOption{handlers.WithMaxBodyBytes(16)},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			if tt.ct != "" {
+				req.Header.Set("Content-Type", tt.ct)
+			}
+
+			got, err := handlers.Bind[createUser](req, tt.opts...)
+
+			if tt.wantStatus != 0 {
+				var be *handlers.BindError
+				if !errors.As(err, &be) {
+					t.Fatalf("want *BindError, got %v", err)
+				}
+				if be.Status != tt.wantStatus {
+					t.Fatalf("status: want %d, got %d (%s)", tt.wantStatus, be.Status, be.Msg)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	h := handlers.JSON(func(req *http.Request, body createUser) types.Responder {
+		return responders.JSONResponse(body, http.StatusCreated)
+	})
+
+	t.Run("decoded body is passed to the handler", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"alice","age":30}`))
+		req.Header.Set("Content-Type", "application/json")
+		h(req).Respond(rr, req)
+
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("want %d, got %d", http.StatusCreated, rr.Code)
+		}
+		if rr.Body.String() != `{"name":"alice","age":30}` {
+			t.Fatalf("unexpected body %s", rr.Body.String())
+		}
+	})
+
+	t.Run("decode failure returns an error response", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":`))
+		req.Header.Set("Content-Type", "application/json")
+		h(req).Respond(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("want %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("want Content-Type application/problem+json, got %s", ct)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// DefaultMethodNotAllowedHandler is the default 405 handler used by the router.
+// Returns a plain text "Method Not Allowed" response with HTTP 405 status.
+// The router sets the Allow header before the responder is invoked.
+func DefaultMethodNotAllowedHandler(r *http.Request) types.Responder {
+	return &textResponder{status: http.StatusMethodNotAllowed, body: "Method Not Allowed"}
+}
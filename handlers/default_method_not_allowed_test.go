@@ -0,0 +1,24 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/handlers"
+)
+
+func TestDefaultMethodNotAllowedHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/foo", nil)
+	responder := handlers.DefaultMethodNotAllowedHandler(r)
+	responder.Respond(rr, r)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	if rr.Body.String() != "Method Not Allowed" {
+		t.Fatalf("want %s, got %s", "Method Not Allowed", rr.Body.String())
+	}
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// DefaultOptionsHandler is the handler the router uses to answer OPTIONS requests
+// for paths without an explicitly registered OPTIONS route.
+// Returns an empty HTTP 204 response; the router sets the Allow header
+// (and any CORS preflight headers) before the responder is invoked.
+func DefaultOptionsHandler(r *http.Request) types.Responder {
+	return &textResponder{status: http.StatusNoContent}
+}
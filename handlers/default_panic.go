@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+// DefaultPanicHandler is the default panic handler used by the router.
+// Logs the recovered value, the ID given to the request by router.RequestIDMiddleware and the stack trace,
+// and returns an RFC 7807 JSON response with HTTP 500 status that does not expose the panic value.
+func DefaultPanicHandler(r *http.Request, recovered any, stack []byte) types.Responder {
+	if id := types.RequestID(r.Context()); id != "" {
+		log.Printf("panic handling %s %s (request_id=%s): %v\n%s", r.Method, r.URL.Path, id, recovered, stack)
+	} else {
+		log.Printf("panic handling %s %s: %v\n%s", r.Method, r.URL.Path, recovered, stack)
+	}
+	return responders.ProblemResponse(responders.Problem{Status: http.StatusInternalServerError})
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/handlers"
+	"github.com/elmq0022/kami/types"
+)
+
+func TestDefaultPanicHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	responder := handlers.DefaultPanicHandler(r, "boom", []byte("stack"))
+	responder.Respond(rr, r)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("want %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("want %s, got %s", "application/problem+json", ct)
+	}
+
+	want := `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/foo"}`
+	if rr.Body.String() != want {
+		t.Fatalf("want %s, got %s", want, rr.Body.String())
+	}
+}
+
+func TestDefaultPanicHandler_LogsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	r = r.WithContext(types.WithRequestID(r.Context(), "abc-123"))
+	handlers.DefaultPanicHandler(r, "boom", []byte("stack"))
+
+	if !strings.Contains(buf.String(), "panic handling GET /foo (request_id=abc-123): boom") {
+		t.Fatalf("unexpected log output: %q", buf.String())
+	}
+
+	// only the ID accepted by the middleware is logged, never the raw header
+	buf.Reset()
+	r = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	r.Header.Set("X-Request-ID", "forged\nline")
+	handlers.DefaultPanicHandler(r, "boom", []byte("stack"))
+
+	if strings.Contains(buf.String(), "forged") {
+		t.Fatalf("unexpected log output: %q", buf.String())
+	}
+}
@@ -0,0 +1,47 @@
+package radix
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// LookupFold finds a route matching path with ASCII letters in its static parts
+// compared case-insensitively, and returns the path with those parts spelled as
+// registered. Parameter and wildcard values are kept as given.
+// For example, with "/api/users/:id" registered, "/API/Users/Bob" yields "/api/users/Bob".
+// Any route matches, whatever its methods. An exact match is preferred over a folded one.
+func (r *Radix) LookupFold(path string) (string, bool) {
+	m := matcher{fold: true}
+	var params types.Params
+	if lookup(r.root, r.normalize(path), &params, &m) == nil {
+		return "", false
+	}
+
+	// parts are recorded while unwinding, deepest first
+	slices.Reverse(m.fixed)
+	return strings.Join(m.fixed, ""), true
+}
+
+// swapCase returns the other case of an ASCII letter, and any other byte unchanged.
+func swapCase(c byte) byte {
+	switch {
+	case 'a' <= c && c <= 'z':
+		return c - 'a' + 'A'
+	case 'A' <= c && c <= 'Z':
+		return c - 'A' + 'a'
+	}
+	return c
+}
+
+// equalFoldASCII reports whether a and b, of equal length, are equal ignoring the case of ASCII letters.
+// Bytes outside ASCII must match exactly, so multi-byte runes are never folded.
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] && swapCase(a[i]) != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,376 @@
+package radix
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/elmq0022/kami/types"
+)
+
+type tokenKind int
+
+const (
+	tokenStatic tokenKind = iota
+	tokenParam
+	tokenWildcard
+)
+
+// token is one piece of a route pattern: a run of literal bytes, a parameter,
+// or the trailing wildcard.
+// A parameter may carry a named constraint (":id<int>") or a regular expression
+// ("{id:[0-9]+}"); match is nil for an unconstrained parameter.
+type token struct {
+	kind       tokenKind
+	text       string
+	name       string
+	constraint string
+	pattern    string
+	match      func(string) bool
+}
+
+// parseRoute splits a route pattern into tokens.
+//
+// The pattern syntax is:
+//   - ":name" or ":name<constraint>", where the name ends at the first byte that
+//     is not a letter, digit or underscore, so "/img/:file.png" and "/api/v:ver" work
+//   - "{name}" or "{name:regex}", where the regex may contain balanced braces
+//   - "/*name" as the last element of the pattern, matching the rest of the path
+//   - anything else is literal text
+//
+// Parameters may start anywhere in a segment but never span a '/', and two
+// parameters may not be adjacent since the boundary between them would be ambiguous.
+func parseRoute(path string) ([]token, error) {
+	var (
+		tokens []token
+		seen   = make(map[string]bool)
+		start  = 0
+	)
+
+	flush := func(end int) {
+		if end > start {
+			tokens = append(tokens, token{kind: tokenStatic, text: path[start:end]})
+		}
+	}
+
+	addParam := func(t token) error {
+		if seen[t.name] {
+			return fmt.Errorf("duplicate parameter %s defined in path %s", t.name, path)
+		}
+		seen[t.name] = true
+
+		if n := len(tokens); n > 0 && tokens[n-1].kind == tokenParam {
+			return fmt.Errorf("adjacent parameters '%s' and '%s' in path '%s'", tokens[n-1].text, t.text, path)
+		}
+		tokens = append(tokens, t)
+		return nil
+	}
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case ':':
+			flush(i)
+
+			j := i + 1
+			for j < len(path) && isNameByte(path[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("got single ':' at position %d in path %s", i, path)
+			}
+			t := token{kind: tokenParam, name: path[i+1 : j]}
+
+			if j < len(path) && path[j] == '<' {
+				end := strings.IndexByte(path[j:], '>')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated constraint in parameter '%s' in path '%s'", path[i:], path)
+				}
+				t.constraint = path[j+1 : j+end]
+				if t.match = constraints[t.constraint]; t.match == nil {
+					return nil, fmt.Errorf("unknown constraint '%s' in parameter '%s' in path '%s'", t.constraint, path[i:j+end+1], path)
+				}
+				j += end + 1
+			}
+
+			t.text = path[i:j]
+			if err := addParam(t); err != nil {
+				return nil, err
+			}
+			i, start = j, j
+
+		case '{':
+			flush(i)
+
+			end, err := closingBrace(path, i)
+			if err != nil {
+				return nil, err
+			}
+
+			name, expr, hasExpr := strings.Cut(path[i+1:end], ":")
+			if name == "" {
+				return nil, fmt.Errorf("missing parameter name in '%s' in path '%s'", path[i:end+1], path)
+			}
+			t := token{kind: tokenParam, text: path[i : end+1], name: name}
+
+			if hasExpr {
+				re, err := regexp.Compile("^(?:" + expr + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern for parameter '%s' in path '%s': %v", name, path, err)
+				}
+				t.pattern = expr
+				t.match = re.MatchString
+			}
+
+			if err := addParam(t); err != nil {
+				return nil, err
+			}
+			i, start = end+1, end+1
+
+		case '}':
+			return nil, fmt.Errorf("unbalanced '}' in path '%s'", path)
+
+		case '*':
+			if i == 0 || path[i-1] != '/' {
+				return nil, fmt.Errorf("wildcard must follow '/' in path '%s'", path)
+			}
+			// the wildcard owns its leading slash so that it can also match an empty rest
+			flush(i - 1)
+
+			name := path[i+1:]
+			if name == "" {
+				return nil, fmt.Errorf("got single '*' at position %d in path %s", i, path)
+			}
+			if strings.ContainsAny(name, "/:*{}") {
+				return nil, fmt.Errorf("wildcard in non-terminal position in path '%s'", path)
+			}
+
+			if seen[name] {
+				return nil, fmt.Errorf("duplicate parameter %s defined in path %s", name, path)
+			}
+			tokens = append(tokens, token{kind: tokenWildcard, text: path[i:], name: name})
+			return tokens, nil
+
+		default:
+			i++
+		}
+	}
+
+	flush(len(path))
+	return tokens, nil
+}
+
+// segmentShape describes what follows the param at tokens[i] up to the end of its
+// path segment, with param names left out: "" for a param taking the whole segment,
+// ".{}" for ":name.{ext}" and ".tar.gz" for ":name.tar.gz". Also returns the number of
+// literal bytes in the shape.
+func segmentShape(tokens []token, i int) (string, int) {
+	var (
+		b       strings.Builder
+		literal int
+	)
+	for _, t := range tokens[i+1:] {
+		switch t.kind {
+		case tokenStatic:
+			text, _, slash := strings.Cut(t.text, "/")
+			b.WriteString(text)
+			literal += len(text)
+			if slash {
+				return b.String(), literal
+			}
+		case tokenParam:
+			b.WriteString("{" + t.constraint + ":" + t.pattern + "}")
+		case tokenWildcard:
+			return b.String(), literal
+		}
+	}
+	return b.String(), literal
+}
+
+// closingBrace returns the index of the brace closing the one at open,
+// allowing nested braces such as regex quantifiers "{2,4}".
+func closingBrace(path string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced '{' in path '%s'", path)
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// cleanPath collapses repeated slashes and drops a trailing slash,
+// so that "/users/", "//users" and "/users" are treated alike.
+func cleanPath(p string) string {
+	if !strings.Contains(p, "//") && (len(p) <= 1 || p[len(p)-1] != '/') {
+		return p
+	}
+
+	var b strings.Builder
+	b.Grow(len(p))
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && (i+1 == len(p) || p[i+1] == '/') {
+			continue
+		}
+		b.WriteByte(p[i])
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// BuildPath fills the parameters and wildcard of a route pattern of the tree with values,
+// escaping them for use in a URL path. The pattern is normalized as the tree normalizes
+// paths. Every parameter of the pattern must be given a value that satisfies its
+// constraint, and every value must be used.
+// The wildcard may be empty; its '/' separators are preserved.
+func (r *Radix) BuildPath(pattern string, values map[string]string) (string, error) {
+	tokens, err := parseRoute(r.normalize(pattern))
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		b    strings.Builder
+		used int
+	)
+
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenStatic:
+			b.WriteString(t.text)
+
+		case tokenParam:
+			v, ok := values[t.name]
+			if !ok || v == "" {
+				return "", fmt.Errorf("missing value for parameter '%s' in route '%s'", t.name, pattern)
+			}
+			if t.match != nil && !t.match(v) {
+				return "", fmt.Errorf("value '%s' does not satisfy parameter '%s' in route '%s'", v, t.text, pattern)
+			}
+			b.WriteString(url.PathEscape(v))
+			used++
+
+		case tokenWildcard:
+			v, ok := values[t.name]
+			if !ok {
+				return "", fmt.Errorf("missing value for wildcard '%s' in route '%s'", t.name, pattern)
+			}
+			b.WriteByte('/')
+			for i, seg := range strings.Split(strings.TrimPrefix(v, "/"), "/") {
+				if i > 0 {
+					b.WriteByte('/')
+				}
+				b.WriteString(url.PathEscape(seg))
+			}
+			used++
+		}
+	}
+
+	if used != len(values) {
+		for name := range values {
+			if !slices.ContainsFunc(tokens, func(t token) bool { return t.kind != tokenStatic && t.name == name }) {
+				return "", fmt.Errorf("unknown parameter '%s' for route '%s'", name, pattern)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// FormatPath rebuilds the path a route pattern of the tree matched from the parameters
+// Lookup captured for it, in order, writing value(p) in place of each parameter and wildcard.
+// The text written is not escaped, so passing the captured values reproduces the path
+// as matched, after normalization; other values replace the captured ones in place.
+func (r *Radix) FormatPath(pattern string, params types.Params, value func(types.Param) string) (string, error) {
+	tokens, err := parseRoute(r.normalize(pattern))
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		b strings.Builder
+		i int
+	)
+
+	for _, t := range tokens {
+		if t.kind == tokenStatic {
+			b.WriteString(t.text)
+			continue
+		}
+
+		if i == len(params) || params[i].Key != t.name {
+			return "", fmt.Errorf("parameters do not match route '%s'", pattern)
+		}
+		p := params[i]
+		i++
+
+		// an empty wildcard may have matched without its '/'
+		if t.kind == tokenWildcard && p.Value != "" {
+			b.WriteByte('/')
+		}
+		if t.kind == tokenParam || p.Value != "" {
+			b.WriteString(value(p))
+		}
+	}
+
+	if i != len(params) {
+		return "", fmt.Errorf("parameters do not match route '%s'", pattern)
+	}
+	return b.String(), nil
+}
+
+// PathParam describes a parameter or wildcard of a route pattern.
+// Constraint and Pattern hold the named constraint or regular expression
+// restricting the parameter, if any.
+type PathParam struct {
+	Name       string
+	Constraint string
+	Pattern    string
+	Wildcard   bool
+}
+
+// Template rewrites a route pattern of the tree in the "{name}" template syntax used by
+// OpenAPI and RFC 6570, e.g. "/users/:id<int>" becomes "/users/{id}", and
+// returns the parameters of the pattern in order. The pattern is normalized as the tree
+// normalizes paths, so a strict tree keeps a trailing slash.
+func (r *Radix) Template(pattern string) (string, []PathParam, error) {
+	tokens, err := parseRoute(r.normalize(pattern))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		b      strings.Builder
+		params []PathParam
+	)
+
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenStatic:
+			b.WriteString(t.text)
+
+		case tokenParam:
+			b.WriteString("{" + t.name + "}")
+			params = append(params, PathParam{Name: t.name, Constraint: t.constraint, Pattern: t.pattern})
+
+		case tokenWildcard:
+			b.WriteString("/{" + t.name + "}")
+			params = append(params, PathParam{Name: t.name, Wildcard: true})
+		}
+	}
+
+	return b.String(), params, nil
+}
@@ -2,23 +2,43 @@ package radix
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/elmq0022/kami/types"
 )
 
+// Node is a node of a byte-level compressed radix trie.
+//
+// Static nodes hold a run of literal bytes in prefix; their static children are
+// indexed by first byte in indices, which is kept parallel to children. A node may
+// also have dynamic children: params, tried in order after the static children, and
+// a wildcard, tried last. A param node records the shapes of the segment remainders
+// registered after it, and the longest literal among them, to detect ambiguous params
+// and order its siblings. A param node matches a non-empty run of bytes that does
+// not contain '/', and a wildcard node matches the rest of the path.
 type Node struct {
 	prefix       string
+	indices      string
 	children     []*Node
 	paramName    string
-	param        *Node
+	constraint   string
+	pattern      string
+	match        func(string) bool
+	shapes       []string
+	literal      int
+	params       []*Node
 	wildcardName string
 	wildcard     *Node
-	terminal     map[string]types.Handler
+	terminal     map[string]types.Route
 }
 
+// Radix is a routing tree. By default paths are normalized before matching,
+// so repeated slashes and a trailing slash are ignored; see NewStrict.
 type Radix struct {
-	root *Node
+	root   *Node
+	strict bool
 }
 
 func New() (*Radix, error) {
@@ -26,144 +46,354 @@ func New() (*Radix, error) {
 	return &r, nil
 }
 
+// NewStrict creates a tree that matches paths exactly as they were registered,
+// so "/users/" and "/users" are distinct routes and "//users" matches neither.
+func NewStrict() (*Radix, error) {
+	r := Radix{root: &Node{}, strict: true}
+	return &r, nil
+}
+
+// normalize returns p as it is matched against the tree.
+func (r *Radix) normalize(p string) string {
+	if r.strict {
+		return p
+	}
+	return cleanPath(p)
+}
+
 func (r *Radix) AddRoute(method string, path string, handler types.Handler) error {
-	if len(path) == 0 || path[0] != '/' {
+	return r.Insert(types.Route{Method: method, Path: path, Handler: handler})
+}
+
+// Insert adds route to the tree. The route is stored as given, so that Routes
+// can report its name and other metadata.
+func (r *Radix) Insert(route types.Route) error {
+	if len(route.Path) == 0 || route.Path[0] != '/' {
 		return fmt.Errorf("path must start with '/'")
 	}
 
-	route := types.Route{Method: method, Path: path, Handler: handler}
-	segments := pathSegments(path)
-
-	if err := validate_NoDuplicateParams(path, segments); err != nil {
+	tokens, err := parseRoute(r.normalize(route.Path))
+	if err != nil {
 		return err
 	}
 
-	return r.insert(route, r.root, segments, 0)
-}
-
-func (r *Radix) insert(route types.Route, node *Node, segments []string, pos int) error {
-	if pos >= len(segments) {
-		if node.terminal == nil {
-			node.terminal = make(map[string]types.Handler)
+	node := r.root
+	for i, t := range tokens {
+		switch t.kind {
+		case tokenStatic:
+			node = node.insertStatic(t.text)
+		case tokenParam:
+			shape, literal := segmentShape(tokens, i)
+			if node, err = node.paramChild(t, shape, literal, route.Path); err != nil {
+				return err
+			}
+		case tokenWildcard:
+			if node, err = node.wildcardChild(t, route.Path); err != nil {
+				return err
+			}
 		}
-		node.terminal[route.Method] = route.Handler
-		return nil
 	}
 
-	seg := segments[pos]
+	if node.terminal == nil {
+		node.terminal = make(map[string]types.Route)
+	}
+	node.terminal[route.Method] = route
+	return nil
+}
+
+// insertStatic walks or extends the trie along s and returns the node at which s ends,
+// splitting an existing node when s diverges from it part way through its prefix.
+func (n *Node) insertStatic(s string) *Node {
+	for len(s) > 0 {
+		i := strings.IndexByte(n.indices, s[0])
+		if i < 0 {
+			child := &Node{prefix: s}
+			n.indices += s[:1]
+			n.children = append(n.children, child)
+			return child
+		}
 
-	if len(seg) >= 1 && seg[0] == ':' {
-		if len(seg) == 1 {
-			return fmt.Errorf("got single ':' at position %d in path %s", pos, route.Path)
-		} else if node.param == nil {
-			node.param = &Node{paramName: seg[1:]}
-			return r.insert(route, node.param, segments, pos+1)
-		} else if node.param.paramName == seg[1:] {
-			return r.insert(route, node.param, segments, pos+1)
-		} else {
-			return fmt.Errorf("parameter name conflict: existing '%s' vs new '%s' in path '%s'", node.param.paramName, seg[1:], route.Path)
+		child := n.children[i]
+		l := commonPrefix(child.prefix, s)
+		if l < len(child.prefix) {
+			split := &Node{
+				prefix:   child.prefix[:l],
+				indices:  child.prefix[l : l+1],
+				children: []*Node{child},
+			}
+			child.prefix = child.prefix[l:]
+			n.children[i] = split
+			child = split
 		}
+
+		n = child
+		s = s[l:]
 	}
+	return n
+}
 
-	if len(seg) >= 1 && seg[0] == '*' {
-		if len(seg) == 1 {
-			return fmt.Errorf("got single '*' at position %d in path %s", pos, route.Path)
-		} else if pos != len(segments)-1 {
-			return fmt.Errorf("wildcard in non-terminal position in path '%s'", route.Path)
+// paramChild returns the param child of n described by t, creating it if needed.
+// Params are keyed by name, constraint and pattern, so differently named params may share
+// a position as long as they are followed by a different shape within their segment
+// (see segmentShape): "/files/:name.{ext}" and "/files/:path" coexist, while
+// "/files/:name" and "/files/:path/raw", or "/f/{name}.{ext}" and "/f/:base.{suffix}",
+// are ambiguous. Lookup tries constrained params first, then params followed by the
+// longest literal within their segment, then the rest in registration order.
+func (n *Node) paramChild(t token, shape string, literal int, path string) (*Node, error) {
+	var child *Node
+	for _, p := range n.params {
+		if p.constraint != t.constraint || p.pattern != t.pattern {
+			continue
+		}
+		if p.paramName == t.name {
+			child = p
+			continue
 		}
-		if node.wildcard == nil {
-			node.wildcard = &Node{wildcardName: seg[1:]}
-			return r.insert(route, node.wildcard, segments, pos+1)
+		if slices.Contains(p.shapes, shape) {
+			return nil, fmt.Errorf("parameter name conflict: existing '%s' vs new '%s' in path '%s'", p.paramName, t.name, path)
 		}
-		return fmt.Errorf("multiple wildcards at same node for path '%s'", route.Path)
 	}
 
-	for _, child := range node.children {
-		if child.prefix == seg {
-			return r.insert(route, child, segments, pos+1)
+	if child == nil {
+		child = &Node{paramName: t.name, constraint: t.constraint, pattern: t.pattern, match: t.match}
+		n.params = append(n.params, child)
+	}
+	if !slices.Contains(child.shapes, shape) {
+		child.shapes = append(child.shapes, shape)
+	}
+	child.literal = max(child.literal, literal)
+
+	slices.SortStableFunc(n.params, func(a, b *Node) int {
+		if (a.match == nil) != (b.match == nil) {
+			if a.match != nil {
+				return -1
+			}
+			return 1
 		}
+		return b.literal - a.literal
+	})
+	return child, nil
+}
+
+// wildcardChild returns the wildcard child of n described by t, creating it if needed.
+func (n *Node) wildcardChild(t token, path string) (*Node, error) {
+	if n.wildcard == nil {
+		n.wildcard = &Node{wildcardName: t.name}
+		return n.wildcard, nil
+	}
+	if n.wildcard.wildcardName != t.name {
+		return nil, fmt.Errorf("multiple wildcards at same node for path '%s'", path)
 	}
+	return n.wildcard, nil
+}
 
-	n := &Node{prefix: seg}
-	node.children = append(node.children, n)
-	return r.insert(route, n, segments, pos+1)
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
 }
 
-func (r *Radix) Lookup(method, path string) (types.Handler, map[string]string, bool) {
-	root := r.root
-	segments := pathSegments(path)
-	params := make(map[string]string)
-	handler, ok := lookup(root, method, segments, 0, params)
-	return handler, params, ok
+// matcher decides which terminal nodes a lookup accepts, and how static parts are compared.
+// A lookup for a method accepts the first node with a handler for it; a lookup
+// with collect set appends the methods of every matching node to allowed and accepts none.
+// A lookup with fold set compares static parts ignoring the case of ASCII letters,
+// accepts the first node with any handler, and records the path as registered in fixed.
+type matcher struct {
+	method  string
+	collect bool
+	allowed []string
+	fold    bool
+	fixed   []string
+}
+
+func (m *matcher) accept(n *Node) bool {
+	if m.collect {
+		for method := range n.terminal {
+			if !slices.Contains(m.allowed, method) {
+				m.allowed = append(m.allowed, method)
+			}
+		}
+		// keep searching so that every matching route contributes
+		return false
+	}
+	if m.fold {
+		return len(n.terminal) > 0
+	}
+	_, ok := n.terminal[m.method]
+	return ok
 }
 
-func lookup(node *Node, method string, segments []string, pos int, params map[string]string) (types.Handler, bool) {
-	var zero types.Handler
+// hasPrefix reports whether path starts with the static prefix.
+func (m *matcher) hasPrefix(path, prefix string) bool {
+	if m.fold {
+		return len(path) >= len(prefix) && equalFoldASCII(path[:len(prefix)], prefix)
+	}
+	return strings.HasPrefix(path, prefix)
+}
 
+// record notes part of the matched path, as registered, while unwinding a fold lookup.
+func (m *matcher) record(s string) {
+	if m.fold {
+		m.fixed = append(m.fixed, s)
+	}
+}
+
+// Lookup returns the route registered for method on the route matching path. Its Path
+// is the pattern as registered, e.g. "/users/:id", rather than the path looked up.
+// The captured URL parameters are appended to params, which callers can reuse
+// across lookups to avoid allocating.
+func (r *Radix) Lookup(method, path string, params *types.Params) (types.Route, bool) {
+	m := matcher{method: method}
+	start := len(*params)
+
+	node := lookup(r.root, r.normalize(path), params, &m)
 	if node == nil {
-		return zero, false
+		*params = (*params)[:start]
+		return types.Route{}, false
+	}
+
+	// params are recorded while unwinding, deepest first
+	slices.Reverse((*params)[start:])
+	return node.terminal[method], true
+}
+
+// Routes returns every registered route, sorted by path and then method.
+func (r *Radix) Routes() types.Routes {
+	routes := types.Routes{}
+	r.root.walk(func(route types.Route) {
+		routes = append(routes, route)
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// walk calls fn for every route stored in the subtree rooted at n.
+func (n *Node) walk(fn func(types.Route)) {
+	for _, route := range n.terminal {
+		fn(route)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+	for _, p := range n.params {
+		p.walk(fn)
+	}
+	if n.wildcard != nil {
+		n.wildcard.walk(fn)
 	}
+}
+
+// Allowed returns the sorted list of methods registered on every route that
+// matches path, regardless of the request method. An empty result means no
+// route matches the path at all.
+func (r *Radix) Allowed(path string) []string {
+	return r.AppendAllowed(nil, path)
+}
 
-	if pos >= len(segments) {
+// AppendAllowed appends the methods Allowed returns for path to methods, which is
+// then sorted, and returns the extended slice. Passing a slice with enough capacity,
+// such as one backed by an array, avoids allocating.
+func (r *Radix) AppendAllowed(methods []string, path string) []string {
+	m := matcher{collect: true, allowed: methods}
+	var params types.Params
+	lookup(r.root, r.normalize(path), &params, &m)
+
+	slices.Sort(m.allowed)
+	return m.allowed
+}
+
+// lookup matches path, the part of the request path left after node, against the
+// children of node depth first in priority order (static, then param, then wildcard)
+// and returns the first terminal node that m accepts.
+// When a subtree fails to produce a match, the search backtracks and tries the next
+// candidate. Params are only recorded along the successful path.
+func lookup(node *Node, path string, params *types.Params, m *matcher) *Node {
+	if path == "" {
 		// Check for terminal handler at this node
-		if handler, ok := node.terminal[method]; ok {
-			return handler, ok
+		if m.accept(node) {
+			return node
 		}
 
 		// Allow wildcard to match empty string
-		if node.wildcard != nil {
-			params[node.wildcard.wildcardName] = ""
-			h, ok := node.wildcard.terminal[method]
-			return h, ok
+		if node.wildcard != nil && m.accept(node.wildcard) {
+			*params = append(*params, types.Param{Key: node.wildcard.wildcardName})
+			return node.wildcard
 		}
 
-		return zero, false
+		return nil
 	}
 
-	for _, child := range node.children {
-		if segments[pos] == child.prefix {
-			h, ok := lookup(child, method, segments, pos+1, params)
-			return h, ok
+	// static children have distinct first bytes, so at most one can match
+	// each case of the first byte
+	if n := lookupStatic(node, path[0], path, params, m); n != nil {
+		return n
+	}
+	if c := swapCase(path[0]); m.fold && c != path[0] {
+		if n := lookupStatic(node, c, path, params, m); n != nil {
+			return n
 		}
 	}
 
-	if node.param != nil {
-		params[node.param.paramName] = segments[pos]
-		h, ok := lookup(node.param, method, segments, pos+1, params)
-		return h, ok
+	if len(node.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+
+		for _, p := range node.params {
+			// a param followed only by '/' or nothing must consume the whole segment;
+			// otherwise try the shortest value first so literals after it can match
+			// values are never empty, which strict trees can otherwise produce for "//"
+			first := 1
+			if p.indices == "" || p.indices == "/" {
+				first = max(end, 1)
+			}
+
+			for e := first; e <= end; e++ {
+				value := path[:e]
+				if p.match != nil && !p.match(value) {
+					continue
+				}
+				if n := lookup(p, path[e:], params, m); n != nil {
+					*params = append(*params, types.Param{Key: p.paramName, Value: value})
+					m.record(value)
+					return n
+				}
+			}
+		}
 	}
 
-	if node.wildcard != nil {
-		params[node.wildcard.wildcardName] = strings.Join(segments[pos:], "/")
-		h, ok := node.wildcard.terminal[method]
-		return h, ok
+	if node.wildcard != nil && path[0] == '/' && m.accept(node.wildcard) {
+		*params = append(*params, types.Param{Key: node.wildcard.wildcardName, Value: path[1:]})
+		m.record(path)
+		return node.wildcard
 	}
 
-	return zero, false
+	return nil
 }
 
-func pathSegments(path string) []string {
-	segments := strings.Split(path, "/")
-
-	p := 0
-	for _, segment := range segments {
-		if segment != "" {
-			segments[p] = segment
-			p++
-		}
+// lookupStatic continues lookup in the static child of node whose prefix starts with c, if any.
+func lookupStatic(node *Node, c byte, path string, params *types.Params, m *matcher) *Node {
+	i := strings.IndexByte(node.indices, c)
+	if i < 0 {
+		return nil
 	}
-	return segments[:p]
-}
 
-func validate_NoDuplicateParams(path string, segments []string) error {
-	seen := make(map[string]bool)
-	for _, seg := range segments {
-		if len(seg) >= 1 && (seg[0] == ':' || seg[0] == '*') {
-			if _, ok := seen[seg[1:]]; ok {
-				return fmt.Errorf("duplicate parameter %s defined in path %s", seg[1:], path)
-			}
-			seen[seg[1:]] = true
-		}
+	child := node.children[i]
+	if !m.hasPrefix(path, child.prefix) {
+		return nil
 	}
-	return nil
+	n := lookup(child, path[len(child.prefix):], params, m)
+	if n != nil {
+		m.record(child.prefix)
+	}
+	return n
 }
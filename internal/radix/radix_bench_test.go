@@ -0,0 +1,200 @@
+package radix_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/internal/radix"
+	"github.com/elmq0022/kami/types"
+)
+
+// legacyNode is the segment-per-node tree with linearly scanned children that
+// the byte-level trie replaced. It is kept here only as a benchmark baseline.
+type legacyNode struct {
+	prefix       string
+	children     []*legacyNode
+	paramName    string
+	param        *legacyNode
+	wildcardName string
+	wildcard     *legacyNode
+	terminal     map[string]types.Handler
+}
+
+func legacySegments(path string) []string {
+	segments := strings.Split(path, "/")
+	p := 0
+	for _, s := range segments {
+		if s != "" {
+			segments[p] = s
+			p++
+		}
+	}
+	return segments[:p]
+}
+
+func (n *legacyNode) insert(method string, segments []string, h types.Handler) {
+	for _, seg := range segments {
+		switch {
+		case seg[0] == ':':
+			if n.param == nil {
+				n.param = &legacyNode{paramName: seg[1:]}
+			}
+			n = n.param
+		case seg[0] == '*':
+			if n.wildcard == nil {
+				n.wildcard = &legacyNode{wildcardName: seg[1:]}
+			}
+			n = n.wildcard
+		default:
+			var next *legacyNode
+			for _, c := range n.children {
+				if c.prefix == seg {
+					next = c
+					break
+				}
+			}
+			if next == nil {
+				next = &legacyNode{prefix: seg}
+				n.children = append(n.children, next)
+			}
+			n = next
+		}
+	}
+	if n.terminal == nil {
+		n.terminal = make(map[string]types.Handler)
+	}
+	n.terminal[method] = h
+}
+
+func (n *legacyNode) lookup(method string, segments []string, pos int, params map[string]string) (types.Handler, bool) {
+	if pos >= len(segments) {
+		h, ok := n.terminal[method]
+		return h, ok
+	}
+	for _, c := range n.children {
+		if segments[pos] == c.prefix {
+			return c.lookup(method, segments, pos+1, params)
+		}
+	}
+	if n.param != nil {
+		params[n.param.paramName] = segments[pos]
+		return n.param.lookup(method, segments, pos+1, params)
+	}
+	if n.wildcard != nil {
+		params[n.wildcard.wildcardName] = strings.Join(segments[pos:], "/")
+		h, ok := n.wildcard.terminal[method]
+		return h, ok
+	}
+	return nil, false
+}
+
+func (n *legacyNode) Lookup(method, path string) (types.Handler, bool) {
+	params := make(map[string]string)
+	return n.lookup(method, legacySegments(path), 0, params)
+}
+
+// benchRoutes mirrors a typical JSON API: shared prefixes, params and a static catch-all.
+var benchRoutes = []string{
+	"/",
+	"/health",
+	"/api/v1/users",
+	"/api/v1/users/:id",
+	"/api/v1/users/:id/posts",
+	"/api/v1/users/:id/posts/:postId",
+	"/api/v1/users/:id/followers",
+	"/api/v1/users/:id/following",
+	"/api/v1/orgs",
+	"/api/v1/orgs/:org",
+	"/api/v1/orgs/:org/members",
+	"/api/v1/orgs/:org/repos",
+	"/api/v1/repos/:owner/:repo",
+	"/api/v1/repos/:owner/:repo/issues",
+	"/api/v1/repos/:owner/:repo/issues/:number",
+	"/api/v1/repos/:owner/:repo/pulls",
+	"/api/v1/repos/:owner/:repo/pulls/:number",
+	"/api/v1/search/repositories",
+	"/api/v1/search/users",
+	"/static/*filepath",
+}
+
+var benchPaths = []string{
+	"/health",
+	"/api/v1/users/42",
+	"/api/v1/users/42/posts/7",
+	"/api/v1/orgs/kami/repos",
+	"/api/v1/repos/elmq0022/kami/pulls/12",
+	"/api/v1/search/users",
+	"/static/js/app.js",
+}
+
+// fanOutRoutes registers many static siblings, the case where linear child scans hurt most.
+func fanOutRoutes() []string {
+	routes := make([]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		routes = append(routes, fmt.Sprintf("/api/resource%03d/items", i))
+	}
+	return routes
+}
+
+type lookupFunc func(method, path string) (types.Handler, bool)
+
+func newTrie(b *testing.B, routes []string) lookupFunc {
+	r, _ := radix.New()
+	for _, p := range routes {
+		if err := r.AddRoute(http.MethodGet, p, MakeTestHandler(p)); err != nil {
+			b.Fatalf("failed to add route %s: %v", p, err)
+		}
+	}
+
+	var params types.Params
+	return func(method, path string) (types.Handler, bool) {
+		params = params[:0]
+		route, ok := r.Lookup(method, path, &params)
+		return route.Handler, ok
+	}
+}
+
+func newLegacy(b *testing.B, routes []string) lookupFunc {
+	root := &legacyNode{}
+	for _, p := range routes {
+		root.insert(http.MethodGet, legacySegments(p), MakeTestHandler(p))
+	}
+	return root.Lookup
+}
+
+func benchmarkLookup(b *testing.B, lookup lookupFunc, paths []string) {
+	for _, p := range paths {
+		if _, ok := lookup(http.MethodGet, p); !ok {
+			b.Fatalf("expected %s to be found", p)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lookup(http.MethodGet, paths[i%len(paths)])
+	}
+}
+
+func BenchmarkLookup_API(b *testing.B) {
+	b.Run("trie", func(b *testing.B) {
+		benchmarkLookup(b, newTrie(b, benchRoutes), benchPaths)
+	})
+	b.Run("legacy", func(b *testing.B) {
+		benchmarkLookup(b, newLegacy(b, benchRoutes), benchPaths)
+	})
+}
+
+func BenchmarkLookup_FanOut(b *testing.B) {
+	routes := fanOutRoutes()
+	paths := []string{"/api/resource000/items", "/api/resource250/items", "/api/resource499/items"}
+
+	b.Run("trie", func(b *testing.B) {
+		benchmarkLookup(b, newTrie(b, routes), paths)
+	})
+	b.Run("legacy", func(b *testing.B) {
+		benchmarkLookup(b, newLegacy(b, routes), paths)
+	})
+}
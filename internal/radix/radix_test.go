@@ -1,7 +1,9 @@
 package radix_test
 
 import (
+	"maps"
 	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/elmq0022/kami/internal/radix"
@@ -27,6 +29,13 @@ func ReadTestHandler(h types.Handler) any {
 	return resp.Value
 }
 
+// lookup adapts Radix.Lookup to return the params as a map for easy comparison.
+func lookup(r *radix.Radix, method, path string) (types.Handler, map[string]string, bool) {
+	var params types.Params
+	route, ok := r.Lookup(method, path, &params)
+	return route.Handler, params.Map(), ok
+}
+
 func TestRadix_AddRoute_Validation(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -73,6 +82,26 @@ func TestRadix_AddRoute_Validation(t *testing.T) {
 			path:      "",
 			wantError: true,
 		},
+		{
+			name:      "constrained parameter",
+			path:      "/user/:id<int>",
+			wantError: false,
+		},
+		{
+			name:      "unknown constraint",
+			path:      "/user/:id<float>",
+			wantError: true,
+		},
+		{
+			name:      "unterminated constraint",
+			path:      "/user/:id<int",
+			wantError: true,
+		},
+		{
+			name:      "constraint without name",
+			path:      "/user/:<int>",
+			wantError: true,
+		},
 		{
 			name:      "wildcard in middle position",
 			path:      "/static/*/more",
@@ -272,15 +301,16 @@ func TestRadix_Lookup(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r, err := radix.New()
-			m := tt.routes[0].Method
-			p := tt.routes[0].Path
-			h := tt.routes[0].Handler
-			r.AddRoute(m, p, h)
 			if err != nil {
 				t.Fatalf("failed to create radix: %v", err)
 			}
+			for _, route := range tt.routes {
+				if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+					t.Fatalf("failed to add route %s: %v", route.Path, err)
+				}
+			}
 
-			h, params, found := r.Lookup(tt.method, tt.path)
+			h, params, found := lookup(r, tt.method, tt.path)
 			if found != tt.wantFound {
 				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
 			}
@@ -301,3 +331,816 @@ func TestRadix_Lookup(t *testing.T) {
 		})
 	}
 }
+
+func TestRadix_Lookup_Backtracking(t *testing.T) {
+	routes := types.Routes{
+		{Path: "/users/me", Method: http.MethodGet, Handler: MakeTestHandler("me")},
+		{Path: "/users/me/settings", Method: http.MethodGet, Handler: MakeTestHandler("me settings")},
+		{Path: "/users/:id/posts", Method: http.MethodGet, Handler: MakeTestHandler("user posts")},
+		{Path: "/users/:id/posts/latest", Method: http.MethodGet, Handler: MakeTestHandler("latest post")},
+		{Path: "/users/:id/*rest", Method: http.MethodGet, Handler: MakeTestHandler("user rest")},
+		{Path: "/users/*path", Method: http.MethodGet, Handler: MakeTestHandler("users catch all")},
+		{Path: "/files/static/index", Method: http.MethodGet, Handler: MakeTestHandler("static index")},
+		{Path: "/files/:dir/index", Method: http.MethodPost, Handler: MakeTestHandler("dir index")},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+		wantFound  bool
+	}{
+		{name: "static wins", method: http.MethodGet, path: "/users/me", wantValue: "me", wantParams: map[string]string{}, wantFound: true},
+		{name: "deeper static wins", method: http.MethodGet, path: "/users/me/settings", wantValue: "me settings", wantParams: map[string]string{}, wantFound: true},
+		{name: "static miss falls back to param", method: http.MethodGet, path: "/users/me/posts", wantValue: "user posts", wantParams: map[string]string{"id": "me"}, wantFound: true},
+		{name: "param deeper match", method: http.MethodGet, path: "/users/me/posts/latest", wantValue: "latest post", wantParams: map[string]string{"id": "me"}, wantFound: true},
+		{name: "param subtree falls back to its wildcard", method: http.MethodGet, path: "/users/42/likes/7", wantValue: "user rest", wantParams: map[string]string{"id": "42", "rest": "likes/7"}, wantFound: true},
+		{name: "static then param miss falls back to wildcard", method: http.MethodGet, path: "/users/me/posts/latest/extra", wantValue: "user rest", wantParams: map[string]string{"id": "me", "rest": "posts/latest/extra"}, wantFound: true},
+		{name: "root wildcard catches unmatched depth", method: http.MethodGet, path: "/users", wantValue: "users catch all", wantParams: map[string]string{"path": ""}, wantFound: true},
+		{name: "method miss on static falls back to param", method: http.MethodPost, path: "/files/static/index", wantValue: "dir index", wantParams: map[string]string{"dir": "static"}, wantFound: true},
+		{name: "no candidate matches", method: http.MethodGet, path: "/files/other/index", wantFound: false},
+	}
+
+	r, _ := radix.New()
+	for _, route := range routes {
+		if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, params, found := lookup(r, tt.method, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if !found {
+				return
+			}
+
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_Constraints(t *testing.T) {
+	routes := types.Routes{
+		{Path: "/items/:id<int>", Method: http.MethodGet, Handler: MakeTestHandler("int")},
+		{Path: "/items/:code<alpha>", Method: http.MethodGet, Handler: MakeTestHandler("alpha")},
+		{Path: "/items/:ref", Method: http.MethodGet, Handler: MakeTestHandler("any")},
+		{Path: "/orders/:id<uuid>/lines/:n<uint>", Method: http.MethodGet, Handler: MakeTestHandler("line")},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+		wantFound  bool
+	}{
+		{name: "int constraint", path: "/items/-12", wantValue: "int", wantParams: map[string]string{"id": "-12"}, wantFound: true},
+		{name: "alpha constraint", path: "/items/abc", wantValue: "alpha", wantParams: map[string]string{"code": "abc"}, wantFound: true},
+		{name: "falls through to unconstrained", path: "/items/a1", wantValue: "any", wantParams: map[string]string{"ref": "a1"}, wantFound: true},
+		{name: "nested constraints", path: "/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301/lines/3", wantValue: "line", wantParams: map[string]string{"id": "3f2504e0-4f89-11d3-9a0c-0305e82c3301", "n": "3"}, wantFound: true},
+		{name: "uint rejects negative", path: "/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301/lines/-3", wantFound: false},
+		{name: "uuid rejects malformed", path: "/orders/3f2504e0/lines/3", wantFound: false},
+	}
+
+	r, _ := radix.New()
+	for _, route := range routes {
+		if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, params, found := lookup(r, http.MethodGet, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if !found {
+				return
+			}
+
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_ConstraintConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     []string
+		wantError bool
+	}{
+		{name: "same constraint different names", paths: []string{"/a/:id<int>", "/a/:num<int>/b"}, wantError: true},
+		{name: "different constraints different names", paths: []string{"/a/:id<int>", "/a/:code<alpha>"}, wantError: false},
+		{name: "constrained and unconstrained", paths: []string{"/a/:id<int>", "/a/:slug"}, wantError: false},
+		{name: "same constraint same name", paths: []string{"/a/:id<int>", "/a/:id<int>/b"}, wantError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			var err error
+			for _, p := range tt.paths {
+				if err = r.AddRoute(http.MethodGet, p, MakeTestHandler(p)); err != nil {
+					break
+				}
+			}
+
+			if tt.wantError && err == nil {
+				t.Fatalf("expected error for paths %v, got nil", tt.paths)
+			}
+			if !tt.wantError && err != nil {
+				t.Fatalf("expected no error for paths %v, got %v", tt.paths, err)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_Patterns(t *testing.T) {
+	routes := types.Routes{
+		{Path: "/files/:name.{ext}", Method: http.MethodGet, Handler: MakeTestHandler("file")},
+		{Path: "/files/{name}.tar.gz", Method: http.MethodGet, Handler: MakeTestHandler("tarball")},
+		{Path: "/files/:path", Method: http.MethodGet, Handler: MakeTestHandler("any file")},
+		{Path: "/files/readme.md", Method: http.MethodGet, Handler: MakeTestHandler("readme")},
+		{Path: "/api/v{version:[0-9]+}/status", Method: http.MethodGet, Handler: MakeTestHandler("status")},
+		{Path: "/api/:section/status", Method: http.MethodGet, Handler: MakeTestHandler("section status")},
+		{Path: "/codes/{code:[A-Z]{3}}", Method: http.MethodGet, Handler: MakeTestHandler("code")},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+		wantFound  bool
+	}{
+		{name: "param and placeholder", path: "/files/report.pdf", wantValue: "file", wantParams: map[string]string{"name": "report", "ext": "pdf"}, wantFound: true},
+		{name: "longer literal wins", path: "/files/backup.tar.gz", wantValue: "tarball", wantParams: map[string]string{"name": "backup"}, wantFound: true},
+		{name: "static beats pattern", path: "/files/readme.md", wantValue: "readme", wantParams: map[string]string{}, wantFound: true},
+		{name: "pattern miss falls back to param", path: "/files/Makefile", wantValue: "any file", wantParams: map[string]string{"path": "Makefile"}, wantFound: true},
+		{name: "regex placeholder with literal prefix", path: "/api/v2/status", wantValue: "status", wantParams: map[string]string{"version": "2"}, wantFound: true},
+		{name: "regex miss falls back to param", path: "/api/vx/status", wantValue: "section status", wantParams: map[string]string{"section": "vx"}, wantFound: true},
+		{name: "regex with quantifier braces", path: "/codes/ABC", wantValue: "code", wantParams: map[string]string{"code": "ABC"}, wantFound: true},
+		{name: "regex is anchored", path: "/codes/ABCD", wantFound: false},
+	}
+
+	r, _ := radix.New()
+	for _, route := range routes {
+		if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, params, found := lookup(r, http.MethodGet, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if !found {
+				return
+			}
+
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_PatternValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     []string
+		wantError bool
+	}{
+		{name: "same pattern same names", paths: []string{"/f/{name}.{ext}", "/f/{name}.{ext}/raw"}, wantError: false},
+		{name: "equivalent patterns different names", paths: []string{"/f/{name}.{ext}", "/f/:base.{suffix}"}, wantError: true},
+		{name: "same literals different regex", paths: []string{"/v{n:[0-9]+}", "/v{tag:[a-z]+}"}, wantError: false},
+		{name: "unbalanced open brace", paths: []string{"/f/{name"}, wantError: true},
+		{name: "unbalanced close brace", paths: []string{"/f/name}"}, wantError: true},
+		{name: "empty placeholder name", paths: []string{"/f/{:[0-9]+}"}, wantError: true},
+		{name: "invalid regex", paths: []string{"/f/{n:[0-9}"}, wantError: true},
+		{name: "duplicate names in pattern", paths: []string{"/f/{n}.{n}"}, wantError: true},
+		{name: "duplicate names across segments", paths: []string{"/:n/{n}.txt"}, wantError: true},
+		{name: "adjacent params", paths: []string{"/f/:a:b"}, wantError: true},
+		{name: "adjacent placeholders", paths: []string{"/f/{a}{b}"}, wantError: true},
+		{name: "wildcard not after slash", paths: []string{"/f/x*rest"}, wantError: true},
+		{name: "different wildcard names", paths: []string{"/f/*a", "/f/*b"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			var err error
+			for _, p := range tt.paths {
+				if err = r.AddRoute(http.MethodGet, p, MakeTestHandler(p)); err != nil {
+					break
+				}
+			}
+
+			if tt.wantError && err == nil {
+				t.Fatalf("expected error for paths %v, got nil", tt.paths)
+			}
+			if !tt.wantError && err != nil {
+				t.Fatalf("expected no error for paths %v, got %v", tt.paths, err)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_MidSegmentParams(t *testing.T) {
+	routes := types.Routes{
+		{Path: "/img/:file.png", Method: http.MethodGet, Handler: MakeTestHandler("png")},
+		{Path: "/img/:file.jpg", Method: http.MethodGet, Handler: MakeTestHandler("jpg")},
+		{Path: "/api/v:ver/users", Method: http.MethodGet, Handler: MakeTestHandler("users")},
+		{Path: "/api/v:ver/users/:id", Method: http.MethodGet, Handler: MakeTestHandler("user")},
+		{Path: "/dl/:name-:version.tgz", Method: http.MethodGet, Handler: MakeTestHandler("package")},
+		{Path: "/geo/:lat<int>,:lng<int>", Method: http.MethodGet, Handler: MakeTestHandler("point")},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+		wantFound  bool
+	}{
+		{name: "param before literal suffix", path: "/img/cat.png", wantValue: "png", wantParams: map[string]string{"file": "cat"}, wantFound: true},
+		{name: "sibling literal suffix", path: "/img/cat.jpg", wantValue: "jpg", wantParams: map[string]string{"file": "cat"}, wantFound: true},
+		{name: "value may contain the literal", path: "/img/cat.png.png", wantValue: "png", wantParams: map[string]string{"file": "cat.png"}, wantFound: true},
+		{name: "suffix required", path: "/img/cat.gif", wantFound: false},
+		{name: "param after literal prefix", path: "/api/v2/users", wantValue: "users", wantParams: map[string]string{"ver": "2"}, wantFound: true},
+		{name: "param after literal prefix nested", path: "/api/v10/users/7", wantValue: "user", wantParams: map[string]string{"ver": "10", "id": "7"}, wantFound: true},
+		{name: "two params in one segment", path: "/dl/kami-1.2.0.tgz", wantValue: "package", wantParams: map[string]string{"name": "kami", "version": "1.2.0"}, wantFound: true},
+		{name: "constrained params in one segment", path: "/geo/-12,34", wantValue: "point", wantParams: map[string]string{"lat": "-12", "lng": "34"}, wantFound: true},
+		{name: "constraint rejects value", path: "/geo/north,34", wantFound: false},
+		{name: "params never span segments", path: "/img/a/b.png", wantFound: false},
+	}
+
+	r, _ := radix.New()
+	for _, route := range routes {
+		if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, params, found := lookup(r, http.MethodGet, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if !found {
+				return
+			}
+
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_DifferentlyNamedParams(t *testing.T) {
+	routes := types.Routes{
+		{Path: "/files/:path", Method: http.MethodGet, Handler: MakeTestHandler("any file")},
+		{Path: "/files/:name.{ext}", Method: http.MethodGet, Handler: MakeTestHandler("file")},
+		{Path: "/files/:stem.tar.gz", Method: http.MethodGet, Handler: MakeTestHandler("tarball")},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+	}{
+		{name: "longest literal suffix wins", path: "/files/backup.tar.gz", wantValue: "tarball", wantParams: map[string]string{"stem": "backup"}},
+		{name: "mid-segment param before whole-segment param", path: "/files/report.pdf", wantValue: "file", wantParams: map[string]string{"name": "report", "ext": "pdf"}},
+		{name: "whole-segment param last", path: "/files/Makefile", wantValue: "any file", wantParams: map[string]string{"path": "Makefile"}},
+	}
+
+	r, _ := radix.New()
+	for _, route := range routes {
+		if err := r.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, params, found := lookup(r, http.MethodGet, tt.path)
+			if !found {
+				t.Fatalf("expected %s to be found", tt.path)
+			}
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_ParamShapeConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     []string
+		wantError bool
+	}{
+		{name: "whole segment and pattern", paths: []string{"/f/:path", "/f/:name.{ext}"}, wantError: false},
+		{name: "different literal suffixes", paths: []string{"/f/:name.png", "/f/:file.jpg"}, wantError: false},
+		{name: "both whole segment", paths: []string{"/f/:path", "/f/:name/raw"}, wantError: true},
+		{name: "same literal suffix", paths: []string{"/f/:name.png", "/f/:file.png"}, wantError: true},
+		{name: "shape added to an existing param", paths: []string{"/f/:name.png", "/f/:file", "/f/:name"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			var err error
+			for _, p := range tt.paths {
+				if err = r.AddRoute(http.MethodGet, p, MakeTestHandler(p)); err != nil {
+					break
+				}
+			}
+
+			if tt.wantError && err == nil {
+				t.Fatalf("expected error for paths %v, got nil", tt.paths)
+			}
+			if !tt.wantError && err != nil {
+				t.Fatalf("expected no error for paths %v, got %v", tt.paths, err)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_PrefixCompression(t *testing.T) {
+	paths := []string{
+		"/s",
+		"/search",
+		"/support",
+		"/supported",
+		"/src/:file",
+		"/contact",
+		"/co",
+		"/c",
+		"/",
+	}
+
+	r, _ := radix.New()
+	for _, p := range paths {
+		if err := r.AddRoute(http.MethodGet, p, MakeTestHandler(p)); err != nil {
+			t.Fatalf("failed to add route %s: %v", p, err)
+		}
+	}
+
+	for _, p := range paths {
+		t.Run(p, func(t *testing.T) {
+			h, _, found := lookup(r, http.MethodGet, p)
+			if !found {
+				t.Fatalf("expected %s to be found", p)
+			}
+			if got := ReadTestHandler(h); got != p {
+				t.Fatalf("expected value %v, got %v", p, got)
+			}
+		})
+	}
+
+	for _, p := range []string{"/se", "/supp", "/supporte", "/con", "/src", "/sx"} {
+		t.Run("miss "+p, func(t *testing.T) {
+			if _, _, found := lookup(r, http.MethodGet, p); found {
+				t.Fatalf("expected %s not to be found", p)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_PathNormalization(t *testing.T) {
+	r, _ := radix.New()
+	r.AddRoute(http.MethodGet, "/users/", MakeTestHandler("users"))
+	r.AddRoute(http.MethodGet, "/static/*fp", MakeTestHandler("static"))
+	r.AddRoute(http.MethodPost, "/static/*fp", MakeTestHandler("upload"))
+
+	tests := []struct {
+		method     string
+		path       string
+		wantValue  any
+		wantParams map[string]string
+	}{
+		{method: http.MethodGet, path: "/users", wantValue: "users", wantParams: map[string]string{}},
+		{method: http.MethodGet, path: "/users/", wantValue: "users", wantParams: map[string]string{}},
+		{method: http.MethodGet, path: "//users", wantValue: "users", wantParams: map[string]string{}},
+		{method: http.MethodGet, path: "/static//css/main.css", wantValue: "static", wantParams: map[string]string{"fp": "css/main.css"}},
+		{method: http.MethodPost, path: "/static/", wantValue: "upload", wantParams: map[string]string{"fp": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			h, params, found := lookup(r, tt.method, tt.path)
+			if !found {
+				t.Fatalf("expected %s to be found", tt.path)
+			}
+			if got := ReadTestHandler(h); got != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, got)
+			}
+			if !maps.Equal(params, tt.wantParams) {
+				t.Fatalf("expected params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_Strict(t *testing.T) {
+	r, _ := radix.NewStrict()
+	r.AddRoute(http.MethodGet, "/users", MakeTestHandler("users"))
+	r.AddRoute(http.MethodGet, "/posts/", MakeTestHandler("posts"))
+	r.AddRoute(http.MethodGet, "/users/:id", MakeTestHandler("user"))
+	r.AddRoute(http.MethodGet, "/users/:id/posts", MakeTestHandler("user posts"))
+	r.AddRoute(http.MethodGet, "/:a/b", MakeTestHandler("b"))
+
+	tests := []struct {
+		path      string
+		wantValue any
+		wantFound bool
+	}{
+		{path: "/users", wantValue: "users", wantFound: true},
+		{path: "/users/42/posts", wantValue: "user posts", wantFound: true},
+		{path: "/users//posts", wantFound: false},
+		{path: "/x/b", wantValue: "b", wantFound: true},
+		{path: "//b", wantFound: false},
+		{path: "/users/", wantFound: false},
+		{path: "//users", wantFound: false},
+		{path: "/posts/", wantValue: "posts", wantFound: true},
+		{path: "/posts", wantFound: false},
+		{path: "/users/42", wantValue: "user", wantFound: true},
+		{path: "/users/42/", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			h, _, found := lookup(r, http.MethodGet, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if found && ReadTestHandler(h) != tt.wantValue {
+				t.Fatalf("expected value %v, got %v", tt.wantValue, ReadTestHandler(h))
+			}
+		})
+	}
+}
+
+func TestRadix_LookupFold(t *testing.T) {
+	r, _ := radix.New()
+	r.AddRoute(http.MethodGet, "/api/users", MakeTestHandler("users"))
+	r.AddRoute(http.MethodGet, "/api/users/:id", MakeTestHandler("user"))
+	r.AddRoute(http.MethodPost, "/api/Uploads/:name.{ext}", MakeTestHandler("upload"))
+	r.AddRoute(http.MethodGet, "/api/users/:id<int>/Posts", MakeTestHandler("posts"))
+	r.AddRoute(http.MethodGet, "/API/status", MakeTestHandler("upper"))
+	r.AddRoute(http.MethodGet, "/api/Status", MakeTestHandler("lower"))
+	r.AddRoute(http.MethodGet, "/files/*fp", MakeTestHandler("files"))
+	r.AddRoute(http.MethodGet, "/café", MakeTestHandler("cafe"))
+
+	tests := []struct {
+		path      string
+		want      string
+		wantFound bool
+	}{
+		{path: "/api/users", want: "/api/users", wantFound: true},
+		{path: "/API/USERS", want: "/api/users", wantFound: true},
+		{path: "/Api/Users/Bob", want: "/api/users/Bob", wantFound: true},
+		{path: "/api/users/42/posts", want: "/api/users/42/Posts", wantFound: true},
+		{path: "/API/UPLOADS/Report.PDF", want: "/api/Uploads/Report.PDF", wantFound: true},
+		{path: "/API/STATUS", want: "/API/status", wantFound: true},
+		{path: "/api/Status", want: "/api/Status", wantFound: true},
+		{path: "/FILES/Css/Main.CSS", want: "/files/Css/Main.CSS", wantFound: true},
+		{path: "/API/USERS/", want: "/api/users", wantFound: true},
+		{path: "/CAFé", want: "/café", wantFound: true},
+		{path: "/CAFÉ", wantFound: false},
+		{path: "/api/posts", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, found := r.LookupFold(tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("expected found=%v, got %v", tt.wantFound, found)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRadix_Lookup_ParamsOrderAndReuse(t *testing.T) {
+	r, _ := radix.New()
+	r.AddRoute(http.MethodGet, "/user/:uid/post/:pid/*rest", MakeTestHandler("post"))
+
+	params := types.Params{{Key: "existing", Value: "kept"}}
+	if _, ok := r.Lookup(http.MethodGet, "/user/alice/post/42/a/b", &params); !ok {
+		t.Fatal("expected route to be found")
+	}
+
+	want := types.Params{
+		{Key: "existing", Value: "kept"},
+		{Key: "uid", Value: "alice"},
+		{Key: "pid", Value: "42"},
+		{Key: "rest", Value: "a/b"},
+	}
+	if !slices.Equal(params, want) {
+		t.Fatalf("want %v, got %v", want, params)
+	}
+
+	if _, ok := r.Lookup(http.MethodGet, "/user/bob", &params); ok {
+		t.Fatal("expected route not to be found")
+	}
+	if !slices.Equal(params, want) {
+		t.Fatalf("a failed lookup must not change params: want %v, got %v", want, params)
+	}
+}
+
+func TestRadix_Lookup_ReturnsRoute(t *testing.T) {
+	r, _ := radix.New()
+	r.Insert(types.Route{Method: http.MethodGet, Path: "/users/:id<int>", Name: "user", Handler: MakeTestHandler("user")})
+	r.Insert(types.Route{Method: http.MethodGet, Path: "/files/*filepath", Handler: MakeTestHandler("files")})
+
+	tests := []struct {
+		path     string
+		wantPath string
+		wantName string
+	}{
+		{path: "/users/42", wantPath: "/users/:id<int>", wantName: "user"},
+		{path: "/users/42/", wantPath: "/users/:id<int>", wantName: "user"},
+		{path: "/files/a/b.txt", wantPath: "/files/*filepath"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var params types.Params
+			route, ok := r.Lookup(http.MethodGet, tt.path, &params)
+			if !ok {
+				t.Fatalf("expected %s to match", tt.path)
+			}
+			if route.Path != tt.wantPath || route.Name != tt.wantName || route.Method != http.MethodGet {
+				t.Errorf("want %s %s %q, got %s %s %q", http.MethodGet, tt.wantPath, tt.wantName, route.Method, route.Path, route.Name)
+			}
+		})
+	}
+
+	var params types.Params
+	if route, ok := r.Lookup(http.MethodGet, "/users/alice", &params); ok || route.Path != "" {
+		t.Errorf("want zero route on a miss, got %+v", route)
+	}
+}
+
+func TestRadix_Allowed(t *testing.T) {
+	r, _ := radix.New()
+	r.AddRoute(http.MethodGet, "/user/:id", MakeTestHandler("get"))
+	r.AddRoute(http.MethodPut, "/user/:id", MakeTestHandler("put"))
+	r.AddRoute(http.MethodPost, "/user", MakeTestHandler("post"))
+	r.AddRoute(http.MethodDelete, "/user/me", MakeTestHandler("delete"))
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{name: "multiple methods", path: "/user/42", want: []string{http.MethodGet, http.MethodPut}},
+		{name: "single method", path: "/user", want: []string{http.MethodPost}},
+		{name: "no match", path: "/posts", want: nil},
+		{name: "union of overlapping routes", path: "/user/me", want: []string{http.MethodDelete, http.MethodGet, http.MethodPut}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.Allowed(tt.path)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRadix_Routes(t *testing.T) {
+	r, _ := radix.New()
+	r.AddRoute(http.MethodPost, "/user", MakeTestHandler("post"))
+	r.AddRoute(http.MethodGet, "/user/:id<int>", MakeTestHandler("get int"))
+	r.AddRoute(http.MethodPut, "/user/:id", MakeTestHandler("put"))
+	r.AddRoute(http.MethodGet, "/user/:id", MakeTestHandler("get"))
+	r.AddRoute(http.MethodGet, "/files/*path", MakeTestHandler("files"))
+	r.Insert(types.Route{Method: http.MethodGet, Path: "/", Name: "home", Middleware: 2, Handler: MakeTestHandler("home")})
+
+	type entry struct {
+		Method, Path, Name string
+		Middleware         int
+	}
+	want := []entry{
+		{Method: http.MethodGet, Path: "/", Name: "home", Middleware: 2},
+		{Method: http.MethodGet, Path: "/files/*path"},
+		{Method: http.MethodPost, Path: "/user"},
+		{Method: http.MethodGet, Path: "/user/:id"},
+		{Method: http.MethodPut, Path: "/user/:id"},
+		{Method: http.MethodGet, Path: "/user/:id<int>"},
+	}
+
+	var got []entry
+	for _, route := range r.Routes() {
+		if route.Handler == nil {
+			t.Fatalf("route %s %s has no handler", route.Method, route.Path)
+		}
+		got = append(got, entry{Method: route.Method, Path: route.Path, Name: route.Name, Middleware: route.Middleware})
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestBuildPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		values  map[string]string
+		strict  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "root", pattern: "/", want: "/"},
+		{name: "static", pattern: "/users/", want: "/users"},
+		{name: "strict trailing slash", pattern: "/users/", strict: true, want: "/users/"},
+		{name: "strict params", pattern: "/users/:id/", values: map[string]string{"id": "42"}, strict: true, want: "/users/42/"},
+		{name: "params", pattern: "/user/:uid/post/:pid", values: map[string]string{"uid": "alice", "pid": "42"}, want: "/user/alice/post/42"},
+		{name: "braces and regex", pattern: "/v{version:[0-9]+}/{name}.json", values: map[string]string{"version": "2", "name": "a b"}, want: "/v2/a%20b.json"},
+		{name: "root wildcard", pattern: "/*fp", values: map[string]string{"fp": "js/app.js"}, want: "/js/app.js"},
+		{name: "empty wildcard", pattern: "/static/*fp", values: map[string]string{"fp": ""}, want: "/static/"},
+		{name: "regex not satisfied", pattern: "/v{version:[0-9]+}", values: map[string]string{"version": "x"}, wantErr: true},
+		{name: "empty param", pattern: "/user/:id", values: map[string]string{"id": ""}, wantErr: true},
+		{name: "missing wildcard", pattern: "/static/*fp", wantErr: true},
+		{name: "unknown value", pattern: "/user/:id", values: map[string]string{"id": "1", "other": "2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			if tt.strict {
+				r, _ = radix.NewStrict()
+			}
+			got, err := r.BuildPath(tt.pattern, tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		redact   string
+		strict   bool
+		want     string
+		wantSame string
+	}{
+		{name: "value repeated earlier", pattern: "/reset/:token/confirm", path: "/reset/re/confirm", redact: "token", want: "/reset/X/confirm"},
+		{name: "params in one segment", pattern: "/files/:name.{ext}", path: "/files/report.pdf", redact: "name", want: "/files/X.pdf"},
+		{name: "regex param", pattern: "/v{version:[0-9]+}/users/:id", path: "/v2/users/2", redact: "id", want: "/v2/users/X"},
+		{name: "wildcard", pattern: "/static/*fp", path: "/static/js/app.js", redact: "fp", want: "/static/X"},
+		{name: "empty wildcard", pattern: "/static/*fp", path: "/static", redact: "fp", want: "/static"},
+		{name: "normalized path", pattern: "/reset/:token/confirm", path: "//reset/re/confirm/", redact: "token", want: "/reset/X/confirm", wantSame: "/reset/re/confirm"},
+		{name: "strict trailing slash", pattern: "/reset/:token/", path: "/reset/re/", redact: "token", strict: true, want: "/reset/X/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			if tt.strict {
+				r, _ = radix.NewStrict()
+			}
+			if err := r.AddRoute("GET", tt.pattern, nil); err != nil {
+				t.Fatalf("AddRoute: %v", err)
+			}
+			var params types.Params
+			if _, ok := r.Lookup("GET", tt.path, &params); !ok {
+				t.Fatalf("no route for %q", tt.path)
+			}
+
+			same, err := r.FormatPath(tt.pattern, params, func(p types.Param) string { return p.Value })
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			wantSame := tt.wantSame
+			if wantSame == "" {
+				wantSame = tt.path
+			}
+			if same != wantSame {
+				t.Fatalf("want captured values to rebuild %q, got %q", wantSame, same)
+			}
+
+			got, err := r.FormatPath(tt.pattern, params, func(p types.Param) string {
+				if p.Key == tt.redact {
+					return "X"
+				}
+				return p.Value
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	r, _ := radix.New()
+	if _, err := r.FormatPath("/users/:id", types.Params{{Key: "name", Value: "x"}}, func(p types.Param) string { return p.Value }); err == nil {
+		t.Fatal("expected an error for params not matching the pattern")
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		strict     bool
+		want       string
+		wantParams []radix.PathParam
+		wantErr    bool
+	}{
+		{name: "static", pattern: "/users", want: "/users"},
+		{name: "root", pattern: "/", want: "/"},
+		{name: "param", pattern: "/users/:id", want: "/users/{id}", wantParams: []radix.PathParam{{Name: "id"}}},
+		{name: "constraint", pattern: "/users/:id<int>/posts", want: "/users/{id}/posts", wantParams: []radix.PathParam{{Name: "id", Constraint: "int"}}},
+		{name: "regex", pattern: "/v{version:[0-9]+}", want: "/v{version}", wantParams: []radix.PathParam{{Name: "version", Pattern: "[0-9]+"}}},
+		{name: "mid-segment", pattern: "/files/:name.{ext}", want: "/files/{name}.{ext}", wantParams: []radix.PathParam{{Name: "name"}, {Name: "ext"}}},
+		{name: "wildcard", pattern: "/static/*fp", want: "/static/{fp}", wantParams: []radix.PathParam{{Name: "fp", Wildcard: true}}},
+		{name: "trailing slash", pattern: "/users/", want: "/users"},
+		{name: "strict trailing slash", pattern: "/users/:id/", strict: true, want: "/users/{id}/", wantParams: []radix.PathParam{{Name: "id"}}},
+		{name: "invalid", pattern: "/users/:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := radix.New()
+			if tt.strict {
+				r, _ = radix.NewStrict()
+			}
+			got, params, err := r.Template(tt.pattern)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+			if !slices.Equal(params, tt.wantParams) {
+				t.Fatalf("want params %v, got %v", tt.wantParams, params)
+			}
+		})
+	}
+}
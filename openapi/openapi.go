@@ -0,0 +1,309 @@
+// Package openapi generates OpenAPI 3.1 documents from the routes registered on a kami router.
+// Routes are documented with router.Router.Describe; the Go types given as request and
+// response bodies are reflected into JSON schemas shared under components.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elmq0022/kami/internal/radix"
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+// Version is the OpenAPI specification version of generated documents.
+const Version = "3.1.0"
+
+// Info describes the API as a whole.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI document. Paths maps each route template, such as
+// "/users/{id}", to its operations.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// Components holds the schemas referenced from operations by name.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem holds the operations available on a path, one per HTTP method.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation describes a single route.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path parameter of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body an operation accepts.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body in one content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Option configures Generate and Handler.
+type Option func(*config)
+
+type config struct {
+	strict bool
+}
+
+// WithStrictPaths keeps the trailing and repeated slashes of route patterns in the path
+// templates, e.g. "/users/" stays "/users/". Use it for routers created with a trailing
+// slash policy other than router.TrailingSlashLenient, which match paths exactly as
+// registered; by default templates are normalized as the lenient policy matches them.
+func WithStrictPaths() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// Generate builds an OpenAPI document describing routes, typically the result of
+// router.Router.Routes. Every route is listed; those described with
+// router.Router.Describe also carry a summary, tags and body schemas.
+// Methods that OpenAPI cannot express, such as CONNECT, are skipped.
+// A route name is used as the operationId of its route; a name shared by routes of several
+// methods is prefixed with the lower-cased method, so "user" becomes "getUser" and "deleteUser".
+// Returns an error if a route pattern is invalid, if two routes of the same method map to
+// the same path template, such as "/users/:id<int>" and "/users/:id", or if two operations
+// end up with the same operationId.
+func Generate(info Info, routes types.Routes, opts ...Option) (*Document, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// templates are normalized as the router's tree normalizes paths
+	newTree := radix.New
+	if cfg.strict {
+		newTree = radix.NewStrict
+	}
+	tree, err := newTree()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	schemas := newSchemaRegistry()
+
+	named := make(map[string]int)
+	for _, route := range routes {
+		if route.Name != "" {
+			named[route.Name]++
+		}
+	}
+
+	// the route registered for each method and template, and the route of each operationId
+	patterns := make(map[string]string)
+	operationIDs := make(map[string]string)
+	for _, route := range routes {
+		template, params, err := tree.Template(route.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		item := doc.Paths[template]
+		if item == nil {
+			item = &PathItem{}
+		}
+		slot := item.operation(route.Method)
+		if slot == nil {
+			continue
+		}
+		if other, ok := patterns[route.Method+" "+template]; ok {
+			return nil, fmt.Errorf("openapi: %s %s and %s %s both map to path %s", route.Method, other, route.Method, route.Path, template)
+		}
+		patterns[route.Method+" "+template] = route.Path
+
+		op := newOperation(route, params, schemas)
+		if route.Name != "" && named[route.Name] > 1 {
+			op.OperationID = operationID(route.Method, route.Name)
+		}
+		if op.OperationID != "" {
+			if other, ok := operationIDs[op.OperationID]; ok {
+				return nil, fmt.Errorf("openapi: %s and %s %s have the same operationId %q", other, route.Method, route.Path, op.OperationID)
+			}
+			operationIDs[op.OperationID] = route.Method + " " + route.Path
+		}
+
+		doc.Paths[template] = item
+		*slot = op
+	}
+
+	if len(schemas.named) > 0 {
+		doc.Components = &Components{Schemas: schemas.named}
+	}
+	return doc, nil
+}
+
+// operationID combines method and name, e.g. "getUser" for GET and "user".
+func operationID(method, name string) string {
+	return strings.ToLower(method) + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Handler returns a handler serving the OpenAPI document for the routes returned by
+// routes, usually the Routes method of the router the handler is mounted on:
+//
+//	r.Prefix("/openapi.json").GET(openapi.Handler(info, r.Routes))
+//
+// The document is generated on the first request, once every route is registered,
+// and served from memory afterwards. A generation failure is answered with a 500 problem.
+func Handler(info Info, routes func() types.Routes, opts ...Option) types.Handler {
+	var (
+		once sync.Once
+		doc  *Document
+		err  error
+	)
+
+	return func(req *http.Request) types.Responder {
+		once.Do(func() {
+			doc, err = Generate(info, routes(), opts...)
+		})
+		if err != nil {
+			return responders.JSONErrorResponse(err.Error(), http.StatusInternalServerError)
+		}
+		return responders.JSONResponse(doc, http.StatusOK)
+	}
+}
+
+// operation returns the field of the path item holding the operation for method,
+// or nil if OpenAPI does not support the method.
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	}
+	return nil
+}
+
+func newOperation(route types.Route, params []radix.PathParam, schemas *schemaRegistry) *Operation {
+	op := &Operation{
+		OperationID: route.Name,
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, p := range params {
+		op.Parameters = append(op.Parameters, pathParameter(p))
+	}
+
+	meta := route.Meta
+	if meta == nil {
+		meta = &types.RouteMeta{}
+	}
+
+	op.Summary = meta.Summary
+	op.Description = meta.Description
+	op.Tags = meta.Tags
+
+	if meta.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schemas.of(meta.Request)}},
+		}
+	}
+
+	status := meta.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := &Response{Description: http.StatusText(status)}
+	if meta.Response != nil {
+		resp.Content = map[string]MediaType{"application/json": {Schema: schemas.of(meta.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	return op
+}
+
+// pathParameter describes a route parameter, translating its constraint into a schema.
+func pathParameter(p radix.PathParam) Parameter {
+	param := Parameter{Name: p.Name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+
+	switch {
+	case p.Wildcard:
+		param.Description = "Remainder of the path, which may contain '/'."
+	case p.Pattern != "":
+		param.Schema.Pattern = "^(?:" + p.Pattern + ")$"
+	default:
+		switch p.Constraint {
+		case "int":
+			param.Schema = &Schema{Type: "integer"}
+		case "uint":
+			min := 0.0
+			param.Schema = &Schema{Type: "integer", Minimum: &min}
+		case "uuid":
+			param.Schema.Format = "uuid"
+		case "alpha":
+			param.Schema.Pattern = "^[A-Za-z]+$"
+		case "alnum":
+			param.Schema.Pattern = "^[A-Za-z0-9]+$"
+		}
+	}
+
+	return param
+}
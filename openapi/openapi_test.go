@@ -0,0 +1,229 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/elmq0022/kami/openapi"
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type createUser struct {
+	Name string `json:"name"`
+}
+
+func testHandler(req *http.Request) types.Responder {
+	return nil
+}
+
+func newRouter() *router.Router {
+	r, _ := router.New()
+	users := r.Prefix("/users")
+	users.Describe(types.RouteMeta{Summary: "List users", Tags: []string{"users"}, Response: []user{}}).GET(testHandler)
+	users.Describe(types.RouteMeta{Summary: "Create a user", Tags: []string{"users"}, Request: createUser{}, Response: user{}, Status: http.StatusCreated}).POST(testHandler)
+	r.Prefix("/users/:id<int>").Name("getUser").Describe(types.RouteMeta{Summary: "Get a user", Response: &user{}}).GET(testHandler)
+	r.Prefix("/users/:id<int>").DELETE(testHandler)
+	r.Prefix("/files/:name.{ext}").GET(testHandler)
+	r.Prefix("/tunnel").CONNECT(testHandler)
+	return r
+}
+
+func TestGenerate(t *testing.T) {
+	doc, err := openapi.Generate(openapi.Info{Title: "Users", Version: "1.0.0"}, newRouter().Routes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Users" {
+		t.Fatalf("unexpected document header: %+v", doc)
+	}
+
+	wantPaths := []string{"/files/{name}.{ext}", "/users", "/users/{id}"}
+	if len(doc.Paths) != len(wantPaths) {
+		t.Fatalf("want paths %v, got %v", wantPaths, doc.Paths)
+	}
+	for _, p := range wantPaths {
+		if doc.Paths[p] == nil {
+			t.Fatalf("missing path %s", p)
+		}
+	}
+
+	list := doc.Paths["/users"].Get
+	if list.Summary != "List users" || !reflect.DeepEqual(list.Tags, []string{"users"}) {
+		t.Fatalf("unexpected list operation: %+v", list)
+	}
+	if got := list.Responses["200"].Content["application/json"].Schema; got.Type != "array" || got.Items.Ref != "#/components/schemas/user" {
+		t.Fatalf("unexpected list response schema: %+v", got)
+	}
+
+	create := doc.Paths["/users"].Post
+	if create.RequestBody == nil || create.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/createUser" {
+		t.Fatalf("unexpected create request body: %+v", create.RequestBody)
+	}
+	if create.Responses["201"] == nil || create.Responses["201"].Description != "Created" {
+		t.Fatalf("want 201 response, got %v", create.Responses)
+	}
+
+	get := doc.Paths["/users/{id}"].Get
+	if get.OperationID != "getUser" {
+		t.Fatalf("want operationId getUser, got %q", get.OperationID)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || get.Parameters[0].In != "path" || !get.Parameters[0].Required || get.Parameters[0].Schema.Type != "integer" {
+		t.Fatalf("unexpected parameters: %+v", get.Parameters)
+	}
+
+	del := doc.Paths["/users/{id}"].Delete
+	if del == nil || del.Summary != "" || del.Responses["200"] == nil {
+		t.Fatalf("undescribed route should be listed with a default response, got %+v", del)
+	}
+
+	if params := doc.Paths["/files/{name}.{ext}"].Get.Parameters; len(params) != 2 || params[0].Name != "name" || params[1].Name != "ext" {
+		t.Fatalf("unexpected mid-segment parameters: %+v", params)
+	}
+
+	if doc.Components == nil || len(doc.Components.Schemas) != 2 {
+		t.Fatalf("want user and createUser component schemas, got %+v", doc.Components)
+	}
+	u := doc.Components.Schemas["user"]
+	if u.Type != "object" || u.Properties["id"].Type != "integer" || u.Properties["name"].Type != "string" || !reflect.DeepEqual(u.Required, []string{"id", "name"}) {
+		t.Fatalf("unexpected user schema: %+v", u)
+	}
+}
+
+func TestGenerate_InvalidRoute(t *testing.T) {
+	routes := types.Routes{{Method: http.MethodGet, Path: "/users/:"}}
+	if _, err := openapi.Generate(openapi.Info{}, routes); err == nil {
+		t.Fatal("expected error for invalid route pattern")
+	}
+}
+
+func TestGenerate_OperationIDs(t *testing.T) {
+	routes := types.Routes{
+		{Method: http.MethodGet, Path: "/users", Name: "listUsers"},
+		{Method: http.MethodGet, Path: "/users/:id", Name: "user"},
+		{Method: http.MethodDelete, Path: "/users/:id", Name: "user"},
+		{Method: http.MethodPut, Path: "/users/:id"},
+	}
+
+	doc, err := openapi.Generate(openapi.Info{}, routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		op   *openapi.Operation
+		want string
+	}{
+		{op: doc.Paths["/users"].Get, want: "listUsers"},
+		{op: doc.Paths["/users/{id}"].Get, want: "getUser"},
+		{op: doc.Paths["/users/{id}"].Delete, want: "deleteUser"},
+		{op: doc.Paths["/users/{id}"].Put, want: ""},
+	}
+	for _, tt := range tests {
+		if tt.op.OperationID != tt.want {
+			t.Errorf("want operationId %q, got %q", tt.want, tt.op.OperationID)
+		}
+	}
+}
+
+func TestGenerate_Collisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes types.Routes
+	}{
+		{
+			name: "patterns with the same template",
+			routes: types.Routes{
+				{Method: http.MethodGet, Path: "/users/:id"},
+				{Method: http.MethodGet, Path: "/users/:id<int>"},
+			},
+		},
+		{
+			name: "duplicate operationId",
+			routes: types.Routes{
+				{Method: http.MethodGet, Path: "/accounts", Name: "getUser"},
+				{Method: http.MethodGet, Path: "/users/:id", Name: "user"},
+				{Method: http.MethodDelete, Path: "/users/:id", Name: "user"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openapi.Generate(openapi.Info{}, tt.routes); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	// the same template is fine for different methods
+	routes := types.Routes{
+		{Method: http.MethodGet, Path: "/users/:id"},
+		{Method: http.MethodDelete, Path: "/users/:id<int>"},
+	}
+	if _, err := openapi.Generate(openapi.Info{}, routes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerate_StrictPaths(t *testing.T) {
+	r, _ := router.New(router.WithTrailingSlash(router.TrailingSlashStrict))
+	r.Prefix("/users/").GET(testHandler)
+	r.Prefix("/users/:id<int>").GET(testHandler)
+
+	doc, err := openapi.Generate(openapi.Info{}, r.Routes(), openapi.WithStrictPaths())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{"/users/", "/users/{id}"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("want path %s, got %v", path, doc.Paths)
+		}
+	}
+
+	// without the option, templates are normalized as the lenient policy matches them
+	doc, err = openapi.Generate(openapi.Info{}, r.Routes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Paths["/users"] == nil {
+		t.Errorf("want path /users, got %v", doc.Paths)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := newRouter()
+	r.Prefix("/openapi.json").GET(openapi.Handler(openapi.Info{Title: "Users", Version: "1.0.0"}, r.Routes))
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("want Content-Type application/json, got %q", ct)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Fatalf("want openapi 3.1.0, got %v", doc["openapi"])
+	}
+	paths := doc["paths"].(map[string]any)
+	if _, ok := paths["/openapi.json"]; !ok {
+		t.Fatalf("want the document route listed, got %v", paths)
+	}
+}
@@ -0,0 +1,66 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/elmq0022/kami/openapi"
+	"github.com/elmq0022/kami/types"
+)
+
+type base struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type node struct {
+	base
+	Name     string            `json:"name"`
+	Note     string            `json:"note,omitempty"`
+	Parent   *node             `json:"parent"`
+	Children []node            `json:"children"`
+	Labels   map[string]string `json:"labels"`
+	Data     []byte            `json:"data"`
+	Size     uint16            `json:"size"`
+	Score    float64           `json:"score"`
+	Any      any               `json:"any"`
+	Secret   string            `json:"-"`
+	internal string
+	Untagged bool
+}
+
+func TestSchemas(t *testing.T) {
+	routes := types.Routes{{
+		Method: http.MethodPost,
+		Path:   "/nodes",
+		Meta:   &types.RouteMeta{Request: node{}},
+	}}
+
+	doc, err := openapi.Generate(openapi.Info{}, routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := json.Marshal(doc.Components.Schemas["node"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"type":"object","properties":{` +
+		`"Untagged":{"type":"boolean"},` +
+		`"any":{},` +
+		`"children":{"type":"array","items":{"$ref":"#/components/schemas/node"}},` +
+		`"created_at":{"type":"string","format":"date-time"},` +
+		`"data":{"type":"string","format":"byte"},` +
+		`"labels":{"type":"object","additionalProperties":{"type":"string"}},` +
+		`"name":{"type":"string"},` +
+		`"note":{"type":"string"},` +
+		`"parent":{"$ref":"#/components/schemas/node"},` +
+		`"score":{"type":"number","format":"double"},` +
+		`"size":{"type":"integer","format":"int32","minimum":0}},` +
+		`"required":["created_at","name","children","labels","data","size","score","any","Untagged"]}`
+	if string(got) != want {
+		t.Fatalf("want %s\ngot  %s", want, got)
+	}
+}
@@ -1,5 +1,5 @@
 // Package responders provides implementations of types.Responder for common response types
-// including JSON responses and static file serving.
+// including JSON responses, RFC 7807 problem details and static file serving.
 package responders
 
 import (
@@ -37,33 +37,11 @@ func (r *jsonResponder) Respond(w http.ResponseWriter, req *http.Request) {
 	w.Write(data)
 }
 
-type jsonErrorResponder struct {
-	status int
-	msg    string
-}
-
-// JSONErrorResponse creates a responder that returns a JSON error message.
-// The response will have Content-Type "application/problem+json" per RFC 7807.
-// The msg parameter becomes the "msg" field in the JSON response.
-// Panics if the error message cannot be marshaled.
-func JSONErrorResponse(msg string, status int) *jsonErrorResponder {
-	return &jsonErrorResponder{msg: msg, status: status}
-}
-
-type jsonError struct {
-	Msg string `json:"msg"`
-}
-
-// Respond writes the error response to the ResponseWriter.
-// Sets Content-Type to "application/problem+json" and marshals the error.
-// Panics if marshaling fails, which will be caught by the router's panic recovery.
-func (e *jsonErrorResponder) Respond(w http.ResponseWriter, req *http.Request) {
-	data, err := json.Marshal(jsonError{Msg: e.msg})
-	if err != nil {
-		panic(fmt.Sprintf("failed to marshal JSON error response: %v", err))
-	}
-
-	w.Header().Set("Content-Type", "application/problem+json")
-	w.WriteHeader(e.status)
-	w.Write(data)
+// JSONErrorResponse creates a responder that returns an RFC 7807 problem document.
+// The response will have Content-Type "application/problem+json".
+// The msg parameter becomes the "detail" member, status the "status" member,
+// and the request URI the "instance" member of the document.
+// Use ProblemResponse to set the type, title or extension members.
+func JSONErrorResponse(msg string, status int) *problemResponder {
+	return ProblemResponse(Problem{Status: status, Detail: msg})
 }
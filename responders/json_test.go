@@ -95,21 +95,21 @@ func TestJSONErrorResponder(t *testing.T) {
 			name:           "not found error",
 			responder:      responders.JSONErrorResponse("resource not found", http.StatusNotFound),
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `{"msg":"resource not found"}`,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"resource not found","instance":"/"}`,
 			expectedCT:     "application/problem+json",
 		},
 		{
 			name:           "bad request error",
 			responder:      responders.JSONErrorResponse("invalid input", http.StatusBadRequest),
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"msg":"invalid input"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input","instance":"/"}`,
 			expectedCT:     "application/problem+json",
 		},
 		{
 			name:           "internal server error",
 			responder:      responders.JSONErrorResponse("something went wrong", http.StatusInternalServerError),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"msg":"something went wrong"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"something went wrong","instance":"/"}`,
 			expectedCT:     "application/problem+json",
 		},
 	}
@@ -0,0 +1,107 @@
+package responders
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem details object.
+// Type defaults to "about:blank", Title to the status text of Status,
+// and Instance to the request URI when the problem is written by a responder.
+// Extensions holds additional members; keys that collide with the standard
+// members are ignored.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+type problemMembers struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// MarshalJSON encodes the standard members in RFC order followed by the extension members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemMembers{
+		Type:     p.Type,
+		Title:    p.Title,
+		Status:   p.Status,
+		Detail:   p.Detail,
+		Instance: p.Instance,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ext := make(map[string]any, len(p.Extensions))
+	for k, v := range p.Extensions {
+		switch k {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		ext[k] = v
+	}
+	if len(ext) == 0 {
+		return data, nil
+	}
+
+	extData, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// splice the extension object's members into the standard member object
+	out := make([]byte, 0, len(data)+len(extData))
+	out = append(out, data[:len(data)-1]...)
+	out = append(out, ',')
+	out = append(out, extData[1:]...)
+	return out, nil
+}
+
+type problemResponder struct {
+	problem Problem
+}
+
+// ProblemResponse creates a responder that writes p as an RFC 7807 problem document
+// with Content-Type "application/problem+json".
+// If p.Status is 0, defaults to 500 Internal Server Error.
+// Panics during Respond if an extension member cannot be marshaled.
+func ProblemResponse(p Problem) *problemResponder {
+	return &problemResponder{problem: p}
+}
+
+// Respond writes the problem document to the ResponseWriter, filling in the
+// type, title and instance members when they are empty.
+// Panics if marshaling fails, which will be caught by the router's panic recovery.
+func (pr *problemResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	p := pr.problem
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" && req != nil && req.URL != nil {
+		p.Instance = req.URL.RequestURI()
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal problem response: %v", err))
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
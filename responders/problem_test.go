@@ -0,0 +1,78 @@
+package responders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/responders"
+)
+
+func TestProblemResponder(t *testing.T) {
+	tests := []struct {
+		name           string
+		problem        responders.Problem
+		target         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "defaults filled from status and request",
+			problem:        responders.Problem{Status: http.StatusNotFound},
+			target:         "/users/42?expand=posts",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"instance":"/users/42?expand=posts"}`,
+		},
+		{
+			name: "all members set",
+			problem: responders.Problem{
+				Type:     "https://example.com/probs/out-of-credit",
+				Title:    "You do not have enough credit.",
+				Status:   http.StatusForbidden,
+				Detail:   "Your current balance is 30, but that costs 50.",
+				Instance: "/account/12345/msgs/abc",
+			},
+			target:         "/",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc"}`,
+		},
+		{
+			name: "extension members",
+			problem: responders.Problem{
+				Status:     http.StatusBadRequest,
+				Detail:     "invalid input",
+				Extensions: map[string]any{"errors": []string{"name is required"}, "balance": 30, "status": 999},
+			},
+			target:         "/users",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input","instance":"/users","balance":30,"errors":["name is required"]}`,
+		},
+		{
+			name:           "zero status defaults to 500",
+			problem:        responders.Problem{},
+			target:         "/",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			responders.ProblemResponse(tt.problem).Respond(w, r)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("expected Content-Type %q, got %q", "application/problem+json", got)
+			}
+
+			if got := w.Body.String(); got != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, got)
+			}
+		})
+	}
+}
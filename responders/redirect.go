@@ -0,0 +1,42 @@
+package responders
+
+import (
+	"net/http"
+	"net/url"
+)
+
+type redirectResponder struct {
+	location string
+	status   int
+}
+
+// RedirectResponse creates a responder that redirects the client to location.
+// The status should be a 3xx code such as http.StatusMovedPermanently or
+// http.StatusPermanentRedirect; if status is 0, defaults to 302 Found.
+// The location is written to the Location header as given, so it must already be escaped.
+func RedirectResponse(location string, status int) *redirectResponder {
+	return &redirectResponder{location: location, status: status}
+}
+
+// PermanentRedirectResponse creates a responder that permanently redirects req to the
+// unescaped path target, keeping its query string. GET and HEAD requests are redirected
+// with 301 Moved Permanently; other methods with 308 Permanent Redirect, so that clients
+// repeat the request with the same method and body.
+func PermanentRedirectResponse(req *http.Request, target string) *redirectResponder {
+	status := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+
+	u := url.URL{Path: target, RawQuery: req.URL.RawQuery}
+	return RedirectResponse(u.String(), status)
+}
+
+// Respond writes the redirect using http.Redirect, which also adds a short HTML body for GET requests.
+func (r *redirectResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	status := r.status
+	if status == 0 {
+		status = http.StatusFound
+	}
+	http.Redirect(w, req, r.location, status)
+}
@@ -0,0 +1,40 @@
+package responders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/responders"
+)
+
+func TestRedirectResponder(t *testing.T) {
+	tests := []struct {
+		name             string
+		location         string
+		status           int
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "default status", location: "/users", expectedStatus: http.StatusFound, expectedLocation: "/users"},
+		{name: "permanent", location: "/users", status: http.StatusMovedPermanently, expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users"},
+		{name: "keeps query", location: "/users?page=2", status: http.StatusPermanentRedirect, expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/users?page=2"},
+		{name: "absolute url", location: "https://example.com/users", status: http.StatusSeeOther, expectedStatus: http.StatusSeeOther, expectedLocation: "https://example.com/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/old", nil)
+			rr := httptest.NewRecorder()
+
+			responders.RedirectResponse(tt.location, tt.status).Respond(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Errorf("expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
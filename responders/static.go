@@ -29,7 +29,8 @@ func NewStaticDirResponder(f fs.FS, prefix string) *staticDirectoryResponder {
 
 // Respond serves static files from the configured filesystem.
 // Automatically redirects directory requests to include a trailing slash.
-// For example, "/static/dir" redirects to "/static/dir/" with a 301 status.
+// For example, "/static/dir" redirects to "/static/dir/", with a 301 status for GET and
+// HEAD requests and 308 otherwise.
 // Delegates to the underlying http.FileServer for actual file serving and security.
 func (r *staticDirectoryResponder) Respond(w http.ResponseWriter, req *http.Request) {
 	trimmed := strings.TrimPrefix(req.URL.Path, r.Prefix)
@@ -38,14 +39,14 @@ func (r *staticDirectoryResponder) Respond(w http.ResponseWriter, req *http.Requ
 	if !strings.HasSuffix(req.URL.Path, "/") {
 		// Empty path is the root of FS
 		if trimmed == "" {
-			http.Redirect(w, req, req.URL.Path+"/", http.StatusMovedPermanently)
+			PermanentRedirectResponse(req, req.URL.Path+"/").Respond(w, req)
 			return
 		}
 
 		// Otherwise, check FS
 		if dir, err := r.FS.Open(trimmed); err == nil {
 			if info, err := dir.Stat(); err == nil && info.IsDir() {
-				http.Redirect(w, req, req.URL.Path+"/", http.StatusMovedPermanently)
+				PermanentRedirectResponse(req, req.URL.Path+"/").Respond(w, req)
 				return
 			}
 		}
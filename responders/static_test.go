@@ -1 +1,46 @@
 package responders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/elmq0022/kami/responders"
+)
+
+func TestStaticDirResponder_DirectoryRedirect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/index.html": &fstest.MapFile{Data: []byte("docs")},
+	}
+	responder := responders.NewStaticDirResponder(fsys, "/static/")
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "GET directory", method: http.MethodGet, path: "/static/docs", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/static/docs/"},
+		{name: "HEAD directory", method: http.MethodHead, path: "/static/docs", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/static/docs/"},
+		{name: "POST directory", method: http.MethodPost, path: "/static/docs", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/static/docs/"},
+		{name: "keeps query", method: http.MethodGet, path: "/static/docs?lang=en", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/static/docs/?lang=en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			responder.Respond(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Errorf("expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// LogField selects an optional attribute of the access log record.
+// The method and path are always logged.
+type LogField uint
+
+const (
+	// LogStatus logs the response status code as "status".
+	LogStatus LogField = 1 << iota
+	// LogBytes logs the number of body bytes written as "bytes".
+	LogBytes
+	// LogDuration logs the time from the handler being called to the response being written as "duration".
+	LogDuration
+	// LogRoute logs the matched route pattern, e.g. "/users/:id", as "route".
+	LogRoute
+	// LogParams logs the URL parameters as the "params" group.
+	LogParams
+	// LogRequestID logs the ID set by RequestIDMiddleware as "request_id".
+	LogRequestID
+	// LogRemoteIP logs the client IP address as "remote_ip".
+	LogRemoteIP
+
+	// LogDefaultFields is used when AccessLogConfig.Fields is zero.
+	LogDefaultFields = LogStatus | LogBytes | LogDuration | LogRoute | LogRequestID | LogRemoteIP
+)
+
+// Redacted replaces redacted values in access log records.
+const Redacted = "[REDACTED]"
+
+// AccessLogConfig configures the AccessLog middleware.
+type AccessLogConfig struct {
+	// Logger receives the records. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Fields selects the optional attributes to log. Defaults to LogDefaultFields.
+	Fields LogField
+
+	// Level returns the level of the record for a response status.
+	// Defaults to Error for 5xx, Warn for 4xx and Info otherwise.
+	Level func(status int) slog.Level
+
+	// Message is the record message. Defaults to "request".
+	Message string
+
+	// Headers lists request headers to log in the "headers" group.
+	Headers []string
+
+	// RedactHeaders lists headers whose values are replaced with Redacted.
+	// Authorization, Cookie and Proxy-Authorization are always redacted.
+	RedactHeaders []string
+
+	// RedactParams lists URL parameters whose values are replaced with Redacted,
+	// both in the "params" group and in the logged path.
+	RedactParams []string
+}
+
+var alwaysRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// AccessLog returns a middleware that writes one structured log record per request
+// using log/slog. Pass it to WithGlobalMiddleware to also log requests that match no
+// route, or to Use to log routed requests only.
+//
+//	r, _ := router.New(router.WithGlobalMiddleware(router.AccessLog(router.AccessLogConfig{
+//		Fields:       router.LogDefaultFields | router.LogParams,
+//		RedactParams: []string{"token"},
+//	})))
+func AccessLog(cfg AccessLogConfig) types.Middleware {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Fields == 0 {
+		cfg.Fields = LogDefaultFields
+	}
+	if cfg.Level == nil {
+		cfg.Level = levelByStatus
+	}
+	if cfg.Message == "" {
+		cfg.Message = "request"
+	}
+
+	redact := make(map[string]bool)
+	for _, h := range slices.Concat(alwaysRedactedHeaders, cfg.RedactHeaders) {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	l := &accessLogger{cfg: cfg, redactHeaders: redact}
+	return func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			start := time.Now()
+
+			var responder types.Responder
+			l.guard(req, nil, start, func() { responder = next(req) })
+			return &accessLogResponder{logger: l, inner: responder, start: start}
+		}
+	}
+}
+
+// levelByStatus is the default AccessLogConfig.Level.
+func levelByStatus(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+type accessLogger struct {
+	cfg           AccessLogConfig
+	redactHeaders map[string]bool
+}
+
+type accessLogResponder struct {
+	logger *accessLogger
+	inner  types.Responder
+	start  time.Time
+}
+
+func (a *accessLogResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	lw := &responseWriter{ResponseWriter: w}
+	a.logger.guard(req, lw, a.start, func() { a.inner.Respond(lw, req) })
+	a.logger.log(req, lw.status(), lw.bytes, time.Since(a.start))
+}
+
+// guard calls fn, logging the request as a 500 before re-panicking if fn panics,
+// since the panic handler's response is written outside the middleware.
+func (l *accessLogger) guard(req *http.Request, lw *responseWriter, start time.Time, fn func()) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			bytes := 0
+			if lw != nil {
+				bytes = lw.bytes
+			}
+			l.log(req, http.StatusInternalServerError, bytes, time.Since(start))
+			panic(rec)
+		}
+	}()
+	fn()
+}
+
+func (l *accessLogger) log(req *http.Request, status, bytes int, duration time.Duration) {
+	ctx := req.Context()
+	level := l.cfg.Level(status)
+	if !l.cfg.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	params := Params(ctx)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", l.path(ctx, req.URL.Path, params)),
+	}
+
+	f := l.cfg.Fields
+	if f&LogStatus != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	if f&LogBytes != 0 {
+		attrs = append(attrs, slog.Int("bytes", bytes))
+	}
+	if f&LogDuration != 0 {
+		attrs = append(attrs, slog.Duration("duration", duration))
+	}
+	if f&LogRoute != 0 {
+		if pattern := RoutePattern(ctx); pattern != "" {
+			attrs = append(attrs, slog.String("route", pattern))
+		}
+	}
+	if f&LogParams != 0 && len(params) > 0 {
+		ps := make([]any, 0, len(params))
+		for _, p := range params {
+			ps = append(ps, slog.String(p.Key, l.param(p)))
+		}
+		attrs = append(attrs, slog.Group("params", ps...))
+	}
+	if f&LogRequestID != 0 {
+		if id := RequestID(ctx); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	if f&LogRemoteIP != 0 {
+		attrs = append(attrs, slog.String("remote_ip", remoteIP(req)))
+	}
+	if len(l.cfg.Headers) > 0 {
+		hs := make([]any, 0, len(l.cfg.Headers))
+		for _, name := range l.cfg.Headers {
+			if v := req.Header.Get(name); v != "" {
+				if l.redactHeaders[http.CanonicalHeaderKey(name)] {
+					v = Redacted
+				}
+				hs = append(hs, slog.String(name, v))
+			}
+		}
+		if len(hs) > 0 {
+			attrs = append(attrs, slog.Group("headers", hs...))
+		}
+	}
+
+	l.cfg.Logger.LogAttrs(ctx, level, l.cfg.Message, attrs...)
+}
+
+// param returns the logged value of p.
+func (l *accessLogger) param(p types.Param) string {
+	for _, name := range l.cfg.RedactParams {
+		if p.Key == name {
+			return Redacted
+		}
+	}
+	return p.Value
+}
+
+// path returns the logged form of path, with the values of redacted params replaced.
+// The params are located by rebuilding the matched path from the route pattern, so a
+// value also appearing elsewhere in the path is left alone. A path rewritten by global
+// middleware before routing is logged with the prefix it lost kept, if any, and a path
+// that cannot be rebuilt, e.g. with params added by WithParams, is redacted as a whole.
+func (l *accessLogger) path(ctx context.Context, path string, params types.Params) string {
+	if !slices.ContainsFunc(params, func(p types.Param) bool { return l.param(p) == Redacted }) {
+		return path
+	}
+
+	st := stateFrom(ctx)
+	if st == nil || st.pattern == "" {
+		return Redacted
+	}
+	matched, err := st.tree.FormatPath(st.pattern, params, func(p types.Param) string { return p.Value })
+	if err != nil {
+		return Redacted
+	}
+
+	redacted, _ := st.tree.FormatPath(st.pattern, params, l.param)
+	if prefix, ok := strings.CutSuffix(path, matched); ok {
+		return prefix + redacted
+	}
+	return redacted
+}
+
+// remoteIP returns the IP address of the client that sent req.
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,269 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+// writeOnly writes a body without calling WriteHeader first.
+type writeOnly string
+
+func (b writeOnly) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte(b))
+}
+
+func newAccessLogRouter(cfg router.AccessLogConfig) *router.Router {
+	r, _ := router.New(router.WithGlobalMiddleware(router.RequestIDMiddleware(router.RequestIDConfig{}), router.AccessLog(cfg)))
+	r.Prefix("/users/:id").GET(func(req *http.Request) types.Responder {
+		return writeOnly("hello")
+	})
+	r.Prefix("/reset/:token/confirm").POST(NewTestHandler(http.StatusInternalServerError, "boom"))
+	r.Prefix("/panic/:token").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+	r.Prefix("/panic/:token/respond").GET(func(req *http.Request) types.Responder {
+		return panicResponder{}
+	})
+	return r
+}
+
+// panicResponder panics after starting the response.
+type panicResponder struct{}
+
+func (panicResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Write([]byte("partial"))
+	panic("boom")
+}
+
+func readRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log record %q: %v", buf.String(), err)
+	}
+	return record
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	r := newAccessLogRouter(router.AccessLogConfig{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.RemoteAddr = "192.0.2.1:54321"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := readRecord(t, &buf)
+	want := map[string]any{
+		"level":      "INFO",
+		"msg":        "request",
+		"method":     "GET",
+		"path":       "/users/42",
+		"status":     float64(200),
+		"bytes":      float64(5),
+		"route":      "/users/:id",
+		"request_id": "abc-123",
+		"remote_ip":  "192.0.2.1",
+	}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("want %s=%v, got %v", k, v, record[k])
+		}
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Errorf("want duration, got %v", record)
+	}
+	if _, ok := record["params"]; ok {
+		t.Errorf("params are not logged by default, got %v", record["params"])
+	}
+}
+
+func TestAccessLog_Levels(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantLevel string
+		wantRoute any
+	}{
+		{name: "success", method: http.MethodGet, path: "/users/42", wantLevel: "INFO", wantRoute: "/users/:id"},
+		{name: "not found", method: http.MethodGet, path: "/missing", wantLevel: "WARN", wantRoute: nil},
+		{name: "server error", method: http.MethodPost, path: "/reset/abc/confirm", wantLevel: "ERROR", wantRoute: "/reset/:token/confirm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newAccessLogRouter(router.AccessLogConfig{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			record := readRecord(t, &buf)
+			if record["level"] != tt.wantLevel {
+				t.Fatalf("want level %s, got %v", tt.wantLevel, record["level"])
+			}
+			if record["route"] != tt.wantRoute {
+				t.Fatalf("want route %v, got %v", tt.wantRoute, record["route"])
+			}
+		})
+	}
+}
+
+func TestAccessLog_FieldsAndRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	r := newAccessLogRouter(router.AccessLogConfig{
+		Logger:        slog.New(slog.NewJSONHandler(&buf, nil)),
+		Fields:        router.LogStatus | router.LogParams,
+		Level:         func(int) slog.Level { return slog.LevelDebug },
+		Message:       "access",
+		Headers:       []string{"User-Agent", "Authorization", "X-Api-Key"},
+		RedactHeaders: []string{"x-api-key"},
+		RedactParams:  []string{"token"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/reset/s3cr3t/confirm", nil)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	req.Header.Set("X-Api-Key", "key")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the handler's level is Info, so debug records are dropped
+	if buf.Len() != 0 {
+		t.Fatalf("want no record below the handler's level, got %s", buf.String())
+	}
+
+	r = newAccessLogRouter(router.AccessLogConfig{
+		Logger:        slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Fields:        router.LogStatus | router.LogParams,
+		Level:         func(int) slog.Level { return slog.LevelDebug },
+		Message:       "access",
+		Headers:       []string{"User-Agent", "Authorization", "X-Api-Key"},
+		RedactHeaders: []string{"x-api-key"},
+		RedactParams:  []string{"token"},
+	})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := readRecord(t, &buf)
+	if record["msg"] != "access" || record["level"] != "DEBUG" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+	if strings.Contains(buf.String(), "s3cr3t") || strings.Contains(buf.String(), `"key"`) {
+		t.Fatalf("secret leaked into log: %s", buf.String())
+	}
+	if record["path"] != "/reset/[REDACTED]/confirm" {
+		t.Fatalf("want redacted path, got %v", record["path"])
+	}
+	if params := record["params"].(map[string]any); params["token"] != router.Redacted {
+		t.Fatalf("want redacted param, got %v", params)
+	}
+	headers := record["headers"].(map[string]any)
+	if headers["User-Agent"] != "test" || headers["Authorization"] != router.Redacted || headers["X-Api-Key"] != router.Redacted {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	for _, k := range []string{"bytes", "duration", "route", "remote_ip"} {
+		if _, ok := record[k]; ok {
+			t.Fatalf("field %s was not selected, got %v", k, record)
+		}
+	}
+}
+
+func TestAccessLog_RedactsPathByPosition(t *testing.T) {
+	var buf bytes.Buffer
+	r := newAccessLogRouter(router.AccessLogConfig{
+		Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+		RedactParams: []string{"token"},
+	})
+
+	// the value also appears earlier in the path, inside "reset"
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/reset/re/confirm", nil))
+
+	record := readRecord(t, &buf)
+	if record["path"] != "/reset/[REDACTED]/confirm" {
+		t.Fatalf("want /reset/[REDACTED]/confirm, got %v", record["path"])
+	}
+}
+
+func TestAccessLog_RedactsStrictPath(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := router.New(
+		router.WithTrailingSlash(router.TrailingSlashStrict),
+		router.WithGlobalMiddleware(router.AccessLog(router.AccessLogConfig{
+			Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+			RedactParams: []string{"token"},
+		})),
+	)
+	r.Prefix("/reset/:token/").GET(NewTestHandler(http.StatusOK, "ok"))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reset/s3cr3t/", nil))
+
+	record := readRecord(t, &buf)
+	if record["path"] != "/reset/[REDACTED]/" {
+		t.Fatalf("want /reset/[REDACTED]/, got %v", record["path"])
+	}
+}
+
+func TestAccessLog_Panic(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantRoute string
+		wantBytes float64
+	}{
+		{name: "handler", path: "/panic/s3cr3t", wantRoute: "/panic/:token"},
+		{name: "responder", path: "/panic/s3cr3t/respond", wantRoute: "/panic/:token/respond", wantBytes: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newAccessLogRouter(router.AccessLogConfig{
+				Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+				RedactParams: []string{"token"},
+			})
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(io.Discard)
+
+			func() {
+				// a panic after the response started aborts the handler
+				defer func() { recover() }()
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			}()
+
+			record := readRecord(t, &buf)
+			if record["status"] != float64(http.StatusInternalServerError) || record["level"] != "ERROR" {
+				t.Fatalf("want an error record with status 500, got %v", record)
+			}
+			if record["route"] != tt.wantRoute || record["bytes"] != tt.wantBytes {
+				t.Fatalf("want route %s and %v bytes, got %v", tt.wantRoute, tt.wantBytes, record)
+			}
+			if strings.Contains(buf.String(), "s3cr3t") {
+				t.Fatalf("secret leaked into log: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_CountsBytes(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	r, _ := router.New()
+	r.Use(router.Logger).Prefix("/hello").GET(func(req *http.Request) types.Responder {
+		return writeOnly("hello")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	if !strings.Contains(buf.String(), "GET /hello - 200 5B") {
+		t.Fatalf("unexpected log line: %q", buf.String())
+	}
+}
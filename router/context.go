@@ -1,23 +1,143 @@
 package router
 
-import "context"
+import (
+	"context"
+	"sync"
+
+	"github.com/elmq0022/kami/internal/radix"
+	"github.com/elmq0022/kami/types"
+)
 
 type contextKey string
 
-const paramsKey contextKey = "paramsKey"
+const stateKey contextKey = "stateKey"
+
+// requestState is the per-request data the router stores in the request context.
+// It is itself the request context, wrapping the context the request arrived with, so
+// that a request costs a single context allocation however many params it has. States
+// are never reused: values read from a context retained after the response has been
+// written, for example by a goroutine or a deferred log call, belong to that request.
+type requestState struct {
+	context.Context
+
+	params types.Params
+
+	// pattern is the pattern of the route matched for the request, if any, and
+	// tree the routing tree it was matched in
+	pattern string
+	tree    *radix.Radix
+
+	// requestID is set by RequestIDMiddleware
+	requestID string
+
+	// scratch is only set while ServeHTTP runs, and is recycled afterwards
+	scratch *scratch
+
+	// paramsBuf backs params for routes with up to 8 params
+	paramsBuf [8]types.Param
+}
+
+func newRequestState(ctx context.Context) *requestState {
+	st := &requestState{Context: ctx}
+	st.params = st.paramsBuf[:0]
+	return st
+}
+
+// Value returns the state itself for stateKey and the request ID, if set, for
+// types.RequestIDContextKey, and delegates any other key.
+func (st *requestState) Value(key any) any {
+	switch key {
+	case stateKey:
+		return st
+	case types.RequestIDContextKey:
+		if st.requestID != "" {
+			return st.requestID
+		}
+	}
+	return st.Context.Value(key)
+}
+
+// scratch holds the objects the router uses while serving a request. They are not
+// reachable from the request context, so they are pooled and recycled once the
+// response has been written.
+type scratch struct {
+	writer responseWriter
+	routed routedResponder
+
+	// recovered is the value of the panic ServeHTTP recovered, if any
+	recovered any
+}
+
+var scratchPool = sync.Pool{
+	New: func() any {
+		return new(scratch)
+	},
+}
+
+func acquireScratch() *scratch {
+	return scratchPool.Get().(*scratch)
+}
+
+// release detaches the scratch from st and recycles it.
+func (st *requestState) release() {
+	sc := st.scratch
+	st.scratch = nil
+	*sc = scratch{}
+	scratchPool.Put(sc)
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	st, _ := ctx.Value(stateKey).(*requestState)
+	return st
+}
 
 // WithParams adds URL parameters to the request context.
-// This is used internally by the router to store matched path parameters.
+// The router stores matched path parameters itself; this is useful for testing handlers
+// or for middleware that rewrites parameters. A route pattern or request ID already in ctx is kept.
 func WithParams(ctx context.Context, params map[string]string) context.Context {
-	return context.WithValue(ctx, paramsKey, params)
+	st := newRequestState(ctx)
+	for k, v := range params {
+		st.params = append(st.params, types.Param{Key: k, Value: v})
+	}
+	if old := stateFrom(ctx); old != nil {
+		st.pattern = old.pattern
+		st.tree = old.tree
+	}
+	return st
+}
+
+// RoutePattern returns the pattern of the route that matched the request, as registered,
+// e.g. "/users/:id" for a request to "/users/42". Unlike the path, the pattern is shared by
+// every request to the route, which makes it suitable for grouping metrics, logs and traces.
+// Returns "" if no route matched, including for 404 and 405 responses.
+func RoutePattern(ctx context.Context) string {
+	if st := stateFrom(ctx); st != nil {
+		return st.pattern
+	}
+	return ""
 }
 
 // GetParams extracts URL parameters from the request context.
 // Parameters come from route definitions like "/users/:id" where :id becomes a parameter.
 // Returns an empty map if no parameters are present in the context.
+// The map is newly allocated on each call and safe to keep; use Param on hot paths.
 func GetParams(ctx context.Context) map[string]string {
-	if p, ok := ctx.Value(paramsKey).(map[string]string); ok {
-		return p
+	return Params(ctx).Map()
+}
+
+// Params returns the URL parameters stored in the request context without allocating.
+// The returned slice is shared with the request context and must not be modified;
+// it stays valid after the response has been written.
+func Params(ctx context.Context) types.Params {
+	if st := stateFrom(ctx); st != nil {
+		return st.params
 	}
-	return make(map[string]string)
+	return nil
+}
+
+// Param returns the value of the named URL parameter, or "" if it is not present.
+// It does not allocate, making it the preferred accessor on hot paths.
+func Param(ctx context.Context, name string) string {
+	v, _ := Params(ctx).Get(name)
+	return v
 }
@@ -3,9 +3,13 @@ package router_test
 import (
 	"context"
 	"maps"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/elmq0022/kami/responders"
 	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
 )
 
 func TestParamsRoundTrip(t *testing.T) {
@@ -22,3 +26,118 @@ func TestParamsRoundTrip(t *testing.T) {
 		t.Fatalf("expected empty map, got %v", empty)
 	}
 }
+
+func TestParamAccessors_ZeroValue(t *testing.T) {
+	ctx := router.WithParams(context.Background(), map[string]string{"id": "42"})
+
+	if got := router.Param(ctx, "id"); got != "42" {
+		t.Fatalf("want %q, got %q", "42", got)
+	}
+
+	if got := router.Param(ctx, "missing"); got != "" {
+		t.Fatalf("want empty string, got %q", got)
+	}
+
+	if v, ok := router.Params(ctx).Get("id"); !ok || v != "42" {
+		t.Fatalf("want 42 true, got %q %v", v, ok)
+	}
+
+	if ps := router.Params(context.Background()); len(ps) != 0 {
+		t.Fatalf("expected no params, got %v", ps)
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	var seen string
+	record := func(req *http.Request) types.Responder {
+		seen = router.RoutePattern(req.Context())
+		return responders.JSONResponse("ok", http.StatusOK)
+	}
+
+	// route middleware sees the pattern too
+	var seenByMiddleware string
+	mw := func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			seenByMiddleware = router.RoutePattern(req.Context())
+			return next(req)
+		}
+	}
+
+	r, _ := router.New()
+	r.Prefix("/users/:id<int>").GET(record, router.Middleware(mw))
+	r.Prefix("/api").Prefix("/files/*filepath").GET(record)
+	r.Prefix("/static").POST(record)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "param", method: http.MethodGet, path: "/users/42", want: "/users/:id<int>"},
+		{name: "nested prefix with wildcard", method: http.MethodGet, path: "/api/files/a/b.txt", want: "/api/files/*filepath"},
+		{name: "head falls back to get", method: http.MethodHead, path: "/users/7", want: "/users/:id<int>"},
+		{name: "not found", method: http.MethodGet, path: "/missing", want: ""},
+		{name: "method not allowed", method: http.MethodGet, path: "/static", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen, seenByMiddleware = "", ""
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if seen != tt.want {
+				t.Errorf("want handler to see %q, got %q", tt.want, seen)
+			}
+			if tt.path == "/users/42" && seenByMiddleware != tt.want {
+				t.Errorf("want middleware to see %q, got %q", tt.want, seenByMiddleware)
+			}
+		})
+	}
+
+	if got := router.RoutePattern(context.Background()); got != "" {
+		t.Errorf("want empty pattern without a routed request, got %q", got)
+	}
+}
+
+func TestContext_ValidAfterResponse(t *testing.T) {
+	var contexts []context.Context
+	r, _ := router.New(router.WithGlobalMiddleware(router.RequestIDMiddleware(router.RequestIDConfig{})))
+	r.Prefix("/users/:id").GET(func(req *http.Request) types.Responder {
+		contexts = append(contexts, req.Context())
+		return responders.JSONResponse("ok", http.StatusOK)
+	})
+	r.Prefix("/orders/:order").GET(func(req *http.Request) types.Responder {
+		contexts = append(contexts, req.Context())
+		return responders.JSONResponse("ok", http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/orders/2", "/users/3"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(router.RequestIDHeader, "req"+path)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		key     string
+		value   string
+		pattern string
+		id      string
+	}{
+		{key: "id", value: "1", pattern: "/users/:id", id: "req/users/1"},
+		{key: "order", value: "2", pattern: "/orders/:order", id: "req/orders/2"},
+		{key: "id", value: "3", pattern: "/users/:id", id: "req/users/3"},
+	}
+	for i, tt := range tests {
+		ctx := contexts[i]
+		if got := router.Params(ctx); len(got) != 1 || got[0].Key != tt.key || got[0].Value != tt.value {
+			t.Errorf("request %d: want params %s=%s, got %v", i, tt.key, tt.value, got)
+		}
+		if got := router.RoutePattern(ctx); got != tt.pattern {
+			t.Errorf("request %d: want pattern %q, got %q", i, tt.pattern, got)
+		}
+		if got := router.RequestID(ctx); got != tt.id {
+			t.Errorf("request %d: want request ID %q, got %q", i, tt.id, got)
+		}
+	}
+}
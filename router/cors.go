@@ -0,0 +1,104 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig describes the cross-origin resource sharing policy applied by the router.
+// AllowedOrigins and AllowedHeaders accept "*" to allow any origin or request header.
+// When AllowCredentials is set, the request origin is echoed instead of "*" as browsers require.
+// A zero MaxAge omits the Access-Control-Max-Age header from preflight responses.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// WithCORS enables CORS handling using the given policy.
+// Preflight requests to paths without an explicit OPTIONS route are answered automatically
+// with the methods registered for the path, and responses to allowed origins are
+// decorated with the Access-Control-Allow-* headers.
+func WithCORS(cfg CORSConfig) Option {
+	return func(r *Router) {
+		r.cors = &cfg
+	}
+}
+
+// allowsOrigin reports whether origin is permitted by the policy.
+func (c *CORSConfig) allowsOrigin(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// decorate sets the CORS headers shared by preflight and actual responses.
+// Returns false if the request carries no Origin or the origin is not allowed.
+// Vary: Origin is set either way, so that a shared cache does not serve a response
+// to a request without an Origin, which lacks the CORS headers, to a CORS request.
+func (c *CORSConfig) decorate(w http.ResponseWriter, req *http.Request) bool {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+
+	origin := req.Header.Get("Origin")
+	if origin == "" || !c.allowsOrigin(origin) {
+		return false
+	}
+
+	if slices.Contains(c.AllowedOrigins, "*") && !c.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if len(c.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+	}
+	return true
+}
+
+// preflight sets the headers answering a CORS preflight request for a path
+// that accepts the given methods.
+func (c *CORSConfig) preflight(w http.ResponseWriter, req *http.Request, allowed []string) {
+	h := w.Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.decorate(w, req) {
+		return
+	}
+	h.Del("Access-Control-Expose-Headers")
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(allowed, ", "))
+
+	if requested := req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		if slices.Contains(c.AllowedHeaders, "*") {
+			h.Set("Access-Control-Allow-Headers", requested)
+		} else if len(c.AllowedHeaders) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+		}
+	}
+
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+}
+
+// isPreflight reports whether req is a CORS preflight request.
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions &&
+		req.Header.Get("Origin") != "" &&
+		req.Header.Get("Access-Control-Request-Method") != ""
+}
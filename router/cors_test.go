@@ -0,0 +1,181 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elmq0022/kami/router"
+)
+
+func TestRouter_AutomaticOptions(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").GET(testHandler)
+	r.Prefix("/users").POST(testHandler)
+
+	t.Run("registered path", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("want %d got %d", http.StatusNoContent, rr.Code)
+		}
+
+		want := "GET, HEAD, OPTIONS, POST"
+		if got := rr.Header().Get("Allow"); got != want {
+			t.Fatalf("Allow: want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodOptions, "/posts", nil)
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("want %d got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestRouter_ExplicitOptionsHandler(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").GET(testHandler)
+	r.Prefix("/users").OPTIONS(NewTestHandler(http.StatusOK, "custom"))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "custom" {
+		t.Fatalf("want %d %q, got %d %q", http.StatusOK, "custom", rr.Code, rr.Body.String())
+	}
+}
+
+func TestWithCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         router.CORSConfig
+		method      string
+		headers     map[string]string
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name:   "preflight from allowed origin",
+			cfg:    router.CORSConfig{AllowedOrigins: []string{"https://app.example"}, AllowedHeaders: []string{"Content-Type"}, MaxAge: 10 * time.Minute},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "content-type",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example",
+				"Access-Control-Allow-Methods": "GET, HEAD, OPTIONS, POST",
+				"Access-Control-Allow-Headers": "Content-Type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "preflight reflects headers for wildcard",
+			cfg:    router.CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "x-custom",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Headers": "x-custom",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+		{
+			name:   "preflight from disallowed origin",
+			cfg:    router.CORSConfig{AllowedOrigins: []string{"https://app.example"}},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example",
+				"Access-Control-Request-Method": http.MethodPost,
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:       "actual request is decorated",
+			cfg:        router.CORSConfig{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Total"}},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example"},
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Expose-Headers": "X-Total",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:       "credentials echo the origin",
+			cfg:        router.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example"},
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:       "same origin request is untouched",
+			cfg:        router.CORSConfig{AllowedOrigins: []string{"*"}},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:       "request without origin varies on origin",
+			cfg:        router.CORSConfig{AllowedOrigins: []string{"https://app.example"}},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "Origin",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := router.New(router.WithCORS(tt.cfg))
+			r.Prefix("/users").GET(testHandler)
+			r.Prefix("/users").POST(testHandler)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/users", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status: want %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			for k, want := range tt.wantHeaders {
+				if got := rr.Header().Get(k); got != want {
+					t.Fatalf("%s: want %q, got %q", k, want, got)
+				}
+			}
+		})
+	}
+}
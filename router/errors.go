@@ -0,0 +1,70 @@
+package router
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+type errorMapping struct {
+	target error
+	status int
+}
+
+// WithErrorStatus maps errors matching target (as reported by errors.Is, so wrapped
+// errors match too) to the given HTTP status in handlers adapted with HandleErr.
+// Mappings are checked in the order they are registered and take precedence over
+// the status reported by a types.StatusError.
+func WithErrorStatus(target error, status int) Option {
+	return func(r *Router) {
+		r.errorMappings = append(r.errorMappings, errorMapping{target: target, status: status})
+	}
+}
+
+// HandleErr adapts a types.ErrorHandler into a types.Handler.
+// When the handler returns an error, it is converted into an RFC 7807 problem response:
+//   - errors matching a target registered with WithErrorStatus use the mapped status
+//     and the target's message as detail, so context added by wrapping is not exposed
+//   - errors implementing types.StatusError (such as *types.HTTPError) use their own
+//     status and message
+//   - any other error is logged and reported as a 500 without exposing its message
+//
+// A handler returning neither a responder nor an error gets an empty 204 No Content response.
+func (r *Router) HandleErr(h types.ErrorHandler) types.Handler {
+	return func(req *http.Request) types.Responder {
+		responder, err := h(req)
+		if err != nil {
+			return r.errorResponder(req, err)
+		}
+		if responder == nil {
+			return noContent{}
+		}
+		return responder
+	}
+}
+
+func (r *Router) errorResponder(req *http.Request, err error) types.Responder {
+	for _, m := range r.errorMappings {
+		if errors.Is(err, m.target) {
+			return responders.JSONErrorResponse(m.target.Error(), m.status)
+		}
+	}
+
+	var se types.StatusError
+	if errors.As(err, &se) {
+		return responders.JSONErrorResponse(se.Error(), se.StatusCode())
+	}
+
+	log.Printf("error handling %s %s: %v", req.Method, req.URL.Path, err)
+	return responders.ProblemResponse(responders.Problem{Status: http.StatusInternalServerError})
+}
+
+// noContent answers with an empty 204 response.
+type noContent struct{}
+
+func (noContent) Respond(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package router_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/handlers"
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+var errUserNotFound = errors.New("user not found")
+
+func TestHandleErr(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no error",
+			err:        nil,
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "sentinel error",
+			err:        errUserNotFound,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found","instance":"/users/42"}`,
+		},
+		{
+			name:       "wrapped sentinel error",
+			err:        fmt.Errorf("loading profile: %w", errUserNotFound),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found","instance":"/users/42"}`,
+		},
+		{
+			name:       "typed error",
+			err:        &types.HTTPError{Status: http.StatusConflict, Msg: "user already exists"},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"type":"about:blank","title":"Conflict","status":409,"detail":"user already exists","instance":"/users/42"}`,
+		},
+		{
+			name:       "wrapped typed error",
+			err:        fmt.Errorf("create: %w", &types.HTTPError{Status: http.StatusForbidden}),
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"type":"about:blank","title":"Forbidden","status":403,"detail":"Forbidden","instance":"/users/42"}`,
+		},
+		{
+			name:       "bind error",
+			err:        &handlers.BindError{Status: http.StatusUnsupportedMediaType, Msg: "Content-Type must be application/json"},
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantBody:   `{"type":"about:blank","title":"Unsupported Media Type","status":415,"detail":"Content-Type must be application/json","instance":"/users/42"}`,
+		},
+		{
+			name:       "unknown error is not exposed",
+			err:        errors.New("connection refused: db:5432"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/users/42"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := router.New(router.WithErrorStatus(errUserNotFound, http.StatusNotFound))
+			r.Prefix("/users/:id").GET(r.HandleErr(func(req *http.Request) (types.Responder, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &testResponder{Status: http.StatusOK, Body: "ok"}, nil
+			}))
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status: want %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			if got := strings.TrimSpace(rr.Body.String()); got != tt.wantBody {
+				t.Fatalf("body: want %s, got %s", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+func TestWithErrorStatus_TakesPrecedenceOverStatusError(t *testing.T) {
+	errGone := &types.HTTPError{Status: http.StatusNotFound, Msg: "gone"}
+
+	r, _ := router.New(router.WithErrorStatus(errGone, http.StatusGone))
+	r.Prefix("/").GET(r.HandleErr(func(req *http.Request) (types.Responder, error) {
+		return nil, errGone
+	}))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusGone {
+		t.Fatalf("want %d, got %d", http.StatusGone, rr.Code)
+	}
+}
+
+func TestHandleErr_NilResponder(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/").DELETE(r.HandleErr(func(req *http.Request) (types.Responder, error) {
+		return nil, nil
+	}))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("want %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("want empty body, got %q", rr.Body.String())
+	}
+}
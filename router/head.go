@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headResponseWriter runs a GET responder on behalf of a HEAD request.
+// The body is discarded, but its length is counted so the Content-Length
+// header reflects what a GET would have sent. Headers are held back until
+// finish is called, once the responder has returned.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	length  int
+	flushed bool
+}
+
+func (hw *headResponseWriter) WriteHeader(code int) {
+	if hw.status == 0 {
+		hw.status = code
+	}
+}
+
+func (hw *headResponseWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+
+	h := hw.Header()
+	if hw.length == 0 && len(b) > 0 && h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(b))
+	}
+
+	hw.length += len(b)
+	return len(b), nil
+}
+
+// finish writes the held-back status and headers to the underlying writer.
+func (hw *headResponseWriter) finish() {
+	if hw.flushed {
+		return
+	}
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+
+	h := hw.Header()
+	if h.Get("Content-Length") == "" && bodyAllowed(hw.status) {
+		h.Set("Content-Length", strconv.Itoa(hw.length))
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
+
+// Flush sends the held-back status and headers without a Content-Length, since the
+// full length is not known yet, and flushes the underlying writer.
+func (hw *headResponseWriter) Flush() {
+	f, ok := hw.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	if !hw.flushed {
+		if hw.status == 0 {
+			hw.status = http.StatusOK
+		}
+		hw.ResponseWriter.WriteHeader(hw.status)
+		hw.flushed = true
+	}
+	f.Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (hw *headResponseWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// bodyAllowed reports whether a response with the given status may carry a body.
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func TestRouter_ImplicitHead(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").GET(func(req *http.Request) types.Responder {
+		return responders.JSONResponse(map[string]string{"name": "alice"}, http.StatusOK)
+	})
+	r.Prefix("/text").GET(NewTestHandler(http.StatusAccepted, "hello"))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantLength string
+		wantCT     string
+	}{
+		{name: "json responder", path: "/users", wantStatus: http.StatusOK, wantLength: "16", wantCT: "application/json"},
+		{name: "status and sniffed content type", path: "/text", wantStatus: http.StatusAccepted, wantLength: "5", wantCT: "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodHead, tt.path, nil)
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status: want %d, got %d", tt.wantStatus, rr.Code)
+			}
+
+			if rr.Body.Len() != 0 {
+				t.Fatalf("body: want empty, got %q", rr.Body.String())
+			}
+
+			if got := rr.Header().Get("Content-Length"); got != tt.wantLength {
+				t.Fatalf("Content-Length: want %s, got %s", tt.wantLength, got)
+			}
+
+			if got := rr.Header().Get("Content-Type"); got != tt.wantCT {
+				t.Fatalf("Content-Type: want %s, got %s", tt.wantCT, got)
+			}
+		})
+	}
+}
+
+func TestRouter_ExplicitHeadTakesPrecedence(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").GET(NewTestHandler(http.StatusOK, "get"))
+	r.Prefix("/users").HEAD(NewTestHandler(http.StatusNoContent, ""))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("want %d, got %d", http.StatusNoContent, rr.Code)
+	}
+}
+
+func TestRouter_HeadWithoutGet(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").POST(testHandler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	want := "OPTIONS, POST"
+	if got := rr.Header().Get("Allow"); got != want {
+		t.Fatalf("Allow: want %q, got %q", want, got)
+	}
+}
+
+// streamResponder writes its chunks, flushing after each one through http.ResponseController.
+type streamResponder struct {
+	chunks []string
+	err    error
+}
+
+func (s *streamResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	rc := http.NewResponseController(w)
+	for _, c := range s.chunks {
+		w.Write([]byte(c))
+		if err := rc.Flush(); err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+func TestRouter_ImplicitHead_Flush(t *testing.T) {
+	stream := &streamResponder{chunks: []string{"a", "b"}}
+	r, _ := router.New()
+	r.Prefix("/events").GET(func(req *http.Request) types.Responder {
+		return stream
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/events", nil))
+
+	if stream.err != nil {
+		t.Fatalf("flush through http.ResponseController failed: %v", stream.err)
+	}
+	if !rr.Flushed {
+		t.Fatal("want the underlying writer flushed")
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: want %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("body: want empty, got %q", rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Length"); got != "" {
+		t.Fatalf("Content-Length: want none for a flushed response, got %s", got)
+	}
+}
@@ -0,0 +1,260 @@
+package router
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// DefaultBuckets are the default latency histogram buckets, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// unmatchedRoute is the route label of requests that match no route.
+const unmatchedRoute = "unmatched"
+
+// MetricsConfig configures a Metrics collector.
+type MetricsConfig struct {
+	// Namespace is prepended to every metric name, e.g. "shop" gives "shop_http_requests_total".
+	Namespace string
+
+	// Buckets are the upper bounds of the latency histogram buckets, in seconds.
+	// Defaults to DefaultBuckets.
+	Buckets []float64
+}
+
+// Metrics collects request counts, in-flight requests and request latencies, and renders
+// them in the Prometheus text exposition format. Series are labelled by method, matched
+// route pattern and status rather than by path, so that their number stays bounded;
+// requests that match no route are labelled route="unmatched", and methods outside the
+// standard ones method="OTHER".
+//
+//	m := router.NewMetrics(router.MetricsConfig{})
+//	r, _ := router.New(router.WithGlobalMiddleware(m.Middleware()))
+//	r.Prefix("/metrics").GET(m.Handler())
+type Metrics struct {
+	names   metricNames
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[seriesKey]uint64
+	latencies map[seriesKey]*histogram
+	inFlight  map[string]int64
+}
+
+type metricNames struct {
+	requests, inFlight, duration string
+}
+
+type seriesKey struct {
+	method, route, status string
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics creates a metrics collector.
+func NewMetrics(cfg MetricsConfig) *Metrics {
+	prefix := ""
+	if cfg.Namespace != "" {
+		prefix = cfg.Namespace + "_"
+	}
+
+	buckets := cfg.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Metrics{
+		names: metricNames{
+			requests: prefix + "http_requests_total",
+			inFlight: prefix + "http_requests_in_flight",
+			duration: prefix + "http_request_duration_seconds",
+		},
+		buckets:   buckets,
+		requests:  make(map[seriesKey]uint64),
+		latencies: make(map[seriesKey]*histogram),
+		inFlight:  make(map[string]int64),
+	}
+}
+
+// Middleware returns a middleware recording every request it wraps. Pass it to
+// WithGlobalMiddleware so that unmatched requests are counted too. A request whose
+// handler or responder panics is recorded with status 500.
+func (m *Metrics) Middleware() types.Middleware {
+	return func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			start := time.Now()
+			method := metricMethod(req.Method)
+			m.addInFlight(method, 1)
+
+			var responder types.Responder
+			m.guard(req, method, start, func() { responder = next(req) })
+			return &metricsResponder{metrics: m, inner: responder, method: method, start: start}
+		}
+	}
+}
+
+// guard calls fn, recording the request as a 500 before re-panicking if fn panics.
+func (m *Metrics) guard(req *http.Request, method string, start time.Time, fn func()) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			m.addInFlight(method, -1)
+			m.observe(req, method, http.StatusInternalServerError, time.Since(start))
+			panic(rec)
+		}
+	}()
+	fn()
+}
+
+type metricsResponder struct {
+	metrics *Metrics
+	inner   types.Responder
+	method  string
+	start   time.Time
+}
+
+func (mr *metricsResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	lw := &responseWriter{ResponseWriter: w}
+	mr.metrics.guard(req, mr.method, mr.start, func() { mr.inner.Respond(lw, req) })
+
+	mr.metrics.addInFlight(mr.method, -1)
+	mr.metrics.observe(req, mr.method, lw.status(), time.Since(mr.start))
+}
+
+func (m *Metrics) addInFlight(method string, delta int64) {
+	m.mu.Lock()
+	m.inFlight[method] += delta
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observe(req *http.Request, method string, status int, d time.Duration) {
+	route := RoutePattern(req.Context())
+	if route == "" {
+		route = unmatchedRoute
+	}
+	key := seriesKey{method: method, route: route, status: strconv.Itoa(status)}
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[key]++
+
+	h := m.latencies[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[key] = h
+	}
+	if i, _ := slices.BinarySearch(m.buckets, seconds); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// metricMethod maps non-standard methods to a single label value.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// Handler returns a handler serving the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() types.Handler {
+	return func(req *http.Request) types.Responder {
+		return m
+	}
+}
+
+// Respond writes the current metrics in the Prometheus text exposition format,
+// making Metrics usable directly as a types.Responder.
+func (m *Metrics) Respond(w http.ResponseWriter, req *http.Request) {
+	var b bytes.Buffer
+	m.write(&b)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
+
+// write renders the metrics with series sorted by their labels, so output is stable.
+func (m *Metrics) write(b *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]seriesKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b seriesKey) int {
+		return cmp.Or(
+			strings.Compare(a.route, b.route),
+			strings.Compare(a.method, b.method),
+			strings.Compare(a.status, b.status),
+		)
+	})
+
+	fmt.Fprintf(b, "# HELP %s Total number of HTTP requests.\n", m.names.requests)
+	fmt.Fprintf(b, "# TYPE %s counter\n", m.names.requests)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s} %d\n", m.names.requests, k.labels(), m.requests[k])
+	}
+
+	methods := make([]string, 0, len(m.inFlight))
+	for method := range m.inFlight {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+
+	fmt.Fprintf(b, "# HELP %s Number of HTTP requests being served.\n", m.names.inFlight)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", m.names.inFlight)
+	for _, method := range methods {
+		fmt.Fprintf(b, "%s{method=%s} %d\n", m.names.inFlight, quoteLabel(method), m.inFlight[method])
+	}
+
+	fmt.Fprintf(b, "# HELP %s HTTP request latency in seconds.\n", m.names.duration)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", m.names.duration)
+	for _, k := range keys {
+		h := m.latencies[k]
+		labels := k.labels()
+
+		var cumulative uint64
+		for i, le := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s,le=%s} %d\n", m.names.duration, labels, quoteLabel(formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", m.names.duration, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", m.names.duration, labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", m.names.duration, labels, h.count)
+	}
+}
+
+func (k seriesKey) labels() string {
+	return "method=" + quoteLabel(k.method) + ",route=" + quoteLabel(k.route) + ",status=" + quoteLabel(k.status)
+}
+
+// quoteLabel quotes a label value, escaping backslashes, double quotes and newlines.
+func quoteLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,103 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func scrape(t *testing.T, r *router.Router) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	return rr.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := router.NewMetrics(router.MetricsConfig{Buckets: []float64{60, 1}})
+	r, _ := router.New(router.WithGlobalMiddleware(m.Middleware()))
+	r.Prefix("/users/:id").GET(testHandler)
+	r.Prefix("/panic").POST(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+	r.Prefix("/metrics").GET(m.Handler())
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodGet, path: "/users/42"},
+		{method: http.MethodGet, path: "/users/43"},
+		{method: http.MethodGet, path: "/missing"},
+		{method: "PURGE", path: "/users/42"},
+		{method: http.MethodPost, path: "/panic"},
+	}
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	body := scrape(t, r)
+
+	wantLines := []string{
+		"# HELP http_requests_total Total number of HTTP requests.",
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",status="405"} 1`,
+		`http_requests_total{method="POST",route="/panic",status="500"} 1`,
+		"# TYPE http_requests_in_flight gauge",
+		// the scrape itself is in flight
+		`http_requests_in_flight{method="GET"} 1`,
+		`http_requests_in_flight{method="POST"} 0`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="1"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="60"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`,
+	}
+	for _, line := range wantLines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+
+	if strings.Contains(body, "/users/42") {
+		t.Errorf("raw paths must not be used as labels:\n%s", body)
+	}
+
+	// the scrape itself is recorded once it has been written
+	body = scrape(t, r)
+	if !strings.Contains(body, `http_requests_total{method="GET",route="/metrics",status="200"} 1`) {
+		t.Errorf("want the first scrape counted, got:\n%s", body)
+	}
+}
+
+func TestMetrics_Namespace(t *testing.T) {
+	m := router.NewMetrics(router.MetricsConfig{Namespace: "shop"})
+	r, _ := router.New(router.WithGlobalMiddleware(m.Middleware()))
+	r.Prefix("/metrics").GET(m.Handler())
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := scrape(t, r)
+
+	for _, line := range []string{
+		`shop_http_requests_total{method="GET",route="/metrics",status="200"} 1`,
+		`shop_http_request_duration_seconds_bucket{method="GET",route="/metrics",status="200",le="0.005"} 1`,
+		`shop_http_request_duration_seconds_bucket{method="GET",route="/metrics",status="200",le="10"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+}
@@ -19,7 +19,42 @@ func WithNotFound(h types.Handler) Option {
 	}
 }
 
-// Logger is a middleware that logs each request with method, path, status code, and duration.
+// WithMethodNotAllowed sets a custom handler for 405 Method Not Allowed responses.
+// The handler is used when a path matches a route but not the request method.
+// The Allow header is already set on the response when the handler's responder runs.
+// If not specified, a default "Method Not Allowed" handler is used.
+func WithMethodNotAllowed(h types.Handler) Option {
+	return func(r *Router) {
+		r.methodNotAllowed = h
+	}
+}
+
+// WithPanicHandler sets a custom handler for requests whose handler or responder panics.
+// The handler receives the recovered value and the stack trace. Its responder is only
+// invoked if the response has not been started yet. Global middleware does not wrap it,
+// so the panic handler sets headers such as the request ID echoed by RequestIDMiddleware
+// itself if wanted; RequestID returns the ID from the request context.
+// If not specified, handlers.DefaultPanicHandler is used.
+func WithPanicHandler(h types.PanicHandler) Option {
+	return func(r *Router) {
+		r.panicHandler = h
+	}
+}
+
+// WithGlobalMiddleware adds middleware that runs before routing, for every request.
+// Unlike middleware added with Use, which is applied to each route's handler at
+// registration, global middleware also wraps the not found, method not allowed,
+// OPTIONS and redirect responses, though not the panic handler's response, and may
+// rewrite the request, e.g. its path or method, before the route is looked up.
+// Middleware is applied in the order given, and the first runs outermost.
+func WithGlobalMiddleware(mws ...types.Middleware) Option {
+	return func(r *Router) {
+		r.global = append(r.global, mws...)
+	}
+}
+
+// Logger is a middleware that logs each request with method, path, status code, body size, and duration,
+// and the request ID if RequestIDMiddleware runs before it.
 func Logger(next types.Handler) types.Handler {
 	return func(req *http.Request) types.Responder {
 		start := time.Now()
@@ -45,23 +80,17 @@ type loggingResponder struct {
 }
 
 func (l *loggingResponder) Respond(w http.ResponseWriter, req *http.Request) {
-	// Wrap the ResponseWriter to capture status code
-	lw := &loggingWriter{ResponseWriter: w, statusCode: 200}
+	// Wrap the ResponseWriter to capture status code and body size
+	lw := &responseWriter{ResponseWriter: w}
 
 	// Call the inner responder
 	l.inner.Respond(lw, req)
 
 	// Log after response is written
 	duration := time.Since(l.start)
-	log.Printf("%s %s - %d (%v)", l.method, l.path, lw.statusCode, duration)
-}
-
-type loggingWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (lw *loggingWriter) WriteHeader(code int) {
-	lw.statusCode = code
-	lw.ResponseWriter.WriteHeader(code)
+	if id := RequestID(req.Context()); id != "" {
+		log.Printf("%s %s - %d %dB (%v) request_id=%s", l.method, l.path, lw.status(), lw.bytes, duration, id)
+		return
+	}
+	log.Printf("%s %s - %d %dB (%v)", l.method, l.path, lw.status(), lw.bytes, duration)
 }
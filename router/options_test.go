@@ -3,6 +3,8 @@ package router_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/elmq0022/kami/router"
@@ -96,6 +98,213 @@ func TestWithNotFound(t *testing.T) {
 	}
 }
 
+func TestWithMethodNotAllowed(t *testing.T) {
+	testMethodNotAllowed := func(r *http.Request) types.Responder {
+		return &testResponder{
+			Status: http.StatusMethodNotAllowed,
+			Body:   "test method not allowed",
+		}
+	}
+
+	r, _ := router.New(router.WithMethodNotAllowed(testMethodNotAllowed))
+	r.Prefix("/").GET(testHandler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want %d got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	if rr.Body.String() != "test method not allowed" {
+		t.Fatalf("want %s, got %s", "test method not allowed", rr.Body.String())
+	}
+
+	if rr.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Fatalf("want Allow %s, got %s", "GET, HEAD, OPTIONS", rr.Header().Get("Allow"))
+	}
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var (
+		gotRecovered any
+		gotStack     []byte
+		gotPath      string
+	)
+
+	testPanicHandler := func(req *http.Request, recovered any, stack []byte) types.Responder {
+		gotRecovered = recovered
+		gotStack = stack
+		gotPath = req.URL.Path
+		return &testResponder{Status: http.StatusServiceUnavailable, Body: "test panic"}
+	}
+
+	r, _ := router.New(router.WithPanicHandler(testPanicHandler))
+	r.Prefix("/boom").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want %d got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	if rr.Body.String() != "test panic" {
+		t.Fatalf("want %s, got %s", "test panic", rr.Body.String())
+	}
+
+	if gotRecovered != "boom" || gotPath != "/boom" || len(gotStack) == 0 {
+		t.Fatalf("unexpected panic handler arguments: %v %q %d bytes", gotRecovered, gotPath, len(gotStack))
+	}
+}
+
+type headerResponder struct {
+	inner types.Responder
+	value string
+}
+
+func (h *headerResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Header().Add("X-Global", h.value)
+	h.inner.Respond(w, req)
+}
+
+func tagMiddleware(value string) types.Middleware {
+	return func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			return &headerResponder{inner: next(req), value: value}
+		}
+	}
+}
+
+func methodOverride(next types.Handler) types.Handler {
+	return func(req *http.Request) types.Responder {
+		if m := req.Header.Get("X-HTTP-Method-Override"); m != "" && req.Method == http.MethodPost {
+			req = req.Clone(req.Context())
+			req.Method = m
+		}
+		return next(req)
+	}
+}
+
+func stripVersion(next types.Handler) types.Handler {
+	return func(req *http.Request) types.Responder {
+		if p, ok := strings.CutPrefix(req.URL.Path, "/v1"); ok {
+			req = req.Clone(req.Context())
+			req.URL.Path = p
+		}
+		return next(req)
+	}
+}
+
+func TestWithGlobalMiddleware(t *testing.T) {
+	r, _ := router.New(
+		router.WithTrailingSlash(router.TrailingSlashRedirect),
+		router.WithGlobalMiddleware(tagMiddleware("a"), tagMiddleware("b")),
+		router.WithGlobalMiddleware(methodOverride, stripVersion),
+	)
+	r.Prefix("/users/:id").GET(func(req *http.Request) types.Responder {
+		return &testResponder{Status: http.StatusOK, Body: router.Param(req.Context(), "id")}
+	})
+	r.Prefix("/users/:id").DELETE(NewTestHandler(http.StatusOK, "deleted"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{name: "matched route", method: http.MethodGet, path: "/users/42", wantStatus: http.StatusOK, wantBody: "42"},
+		{name: "not found", method: http.MethodGet, path: "/posts", wantStatus: http.StatusNotFound, wantBody: "Not Found"},
+		{name: "method not allowed", method: http.MethodPut, path: "/users/42", wantStatus: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+		{name: "automatic options", method: http.MethodOptions, path: "/users/42", wantStatus: http.StatusNoContent, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+		{name: "redirect", method: http.MethodGet, path: "/users/42/", wantStatus: http.StatusMovedPermanently},
+		{name: "path rewritten before lookup", method: http.MethodGet, path: "/v1/users/7", wantStatus: http.StatusOK, wantBody: "7"},
+		{name: "method rewritten before lookup", method: http.MethodPost, path: "/users/42", header: http.MethodDelete, wantStatus: http.StatusOK, wantBody: "deleted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-HTTP-Method-Override", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Fatalf("want body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+			if got := rr.Header().Get("Allow"); got != tt.wantAllow {
+				t.Fatalf("want Allow %q, got %q", tt.wantAllow, got)
+			}
+			// the first middleware runs outermost
+			if got := rr.Header().Values("X-Global"); !slices.Equal(got, []string{"a", "b"}) {
+				t.Fatalf("want X-Global [a b], got %v", got)
+			}
+		})
+	}
+}
+
+func TestWithGlobalMiddleware_NextTwice(t *testing.T) {
+	// a middleware may route a request more than once, e.g. to retry it
+	twice := func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			first := next(req)
+			other := req.Clone(req.Context())
+			other.URL.Path = "/other"
+			next(other)
+			return first
+		}
+	}
+
+	r, _ := router.New(router.WithGlobalMiddleware(twice))
+	r.Prefix("/users/:id").GET(func(req *http.Request) types.Responder {
+		return &testResponder{Status: http.StatusOK, Body: router.Param(req.Context(), "id")}
+	})
+	r.Prefix("/other").GET(NewTestHandler(http.StatusOK, "other"))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "42" {
+		t.Fatalf("want 200 42, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestWithGlobalMiddleware_Panic(t *testing.T) {
+	r, _ := router.New(
+		router.WithGlobalMiddleware(tagMiddleware("a")),
+		router.WithPanicHandler(func(req *http.Request, recovered any, stack []byte) types.Responder {
+			return &testResponder{Status: http.StatusInternalServerError, Body: "panic"}
+		}),
+	)
+	r.Prefix("/panic").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("want status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+	// global middleware does not wrap the panic handler's response
+	if got := rr.Header().Values("X-Global"); len(got) != 0 {
+		t.Fatalf("want no X-Global, got %v", got)
+	}
+}
+
 func TestLogger(t *testing.T) {
 	r, _ := router.New()
 	r = r.Use(router.Logger)
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/elmq0022/kami/internal/radix"
+	"github.com/elmq0022/kami/types"
+)
+
+// ParamAs parses the named URL parameter with the given parse function.
+// A parameter that is not defined by the matched route yields a 500 *types.HTTPError,
+// and a parse failure yields a 400 *types.HTTPError wrapping the parse error, so
+// handlers adapted with HandleErr can return the error unchanged.
+func ParamAs[T any](ctx context.Context, name string, parse func(string) (T, error)) (T, error) {
+	var zero T
+
+	raw, ok := Params(ctx).Get(name)
+	if !ok {
+		return zero, &types.HTTPError{
+			Status: http.StatusInternalServerError,
+			Msg:    fmt.Sprintf("path parameter %q is not defined by the route", name),
+		}
+	}
+
+	v, err := parse(raw)
+	if err != nil {
+		return zero, &types.HTTPError{
+			Status: http.StatusBadRequest,
+			Msg:    fmt.Sprintf("path parameter %q is invalid: %q", name, raw),
+			Err:    err,
+		}
+	}
+	return v, nil
+}
+
+// ParamInt returns the named URL parameter parsed as an int.
+// See ParamAs for the errors returned.
+func ParamInt(ctx context.Context, name string) (int, error) {
+	return ParamAs(ctx, name, strconv.Atoi)
+}
+
+// ParamInt64 returns the named URL parameter parsed as an int64.
+// See ParamAs for the errors returned.
+func ParamInt64(ctx context.Context, name string) (int64, error) {
+	return ParamAs(ctx, name, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// ParamUUID returns the named URL parameter validated as a UUID in its canonical
+// 8-4-4-4-12 hexadecimal form, normalized to lower case.
+// See ParamAs for the errors returned.
+func ParamUUID(ctx context.Context, name string) (string, error) {
+	return ParamAs(ctx, name, parseUUID)
+}
+
+func parseUUID(s string) (string, error) {
+	if !radix.IsUUID(s) {
+		return "", errors.New("uuid must be in the 8-4-4-4-12 hexadecimal form")
+	}
+	return strings.ToLower(s), nil
+}
@@ -0,0 +1,104 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func TestParamAccessors(t *testing.T) {
+	ctx := router.WithParams(context.Background(), map[string]string{
+		"id":   "42",
+		"big":  "9000000000",
+		"uuid": "3F2504E0-4F89-11D3-9A0C-0305E82C3301",
+		"bad":  "abc",
+	})
+
+	t.Run("int", func(t *testing.T) {
+		got, err := router.ParamInt(ctx, "id")
+		if err != nil || got != 42 {
+			t.Fatalf("want 42, got %d (%v)", got, err)
+		}
+	})
+
+	t.Run("int64", func(t *testing.T) {
+		got, err := router.ParamInt64(ctx, "big")
+		if err != nil || got != 9000000000 {
+			t.Fatalf("want 9000000000, got %d (%v)", got, err)
+		}
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		want := "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+		got, err := router.ParamUUID(ctx, "uuid")
+		if err != nil || got != want {
+			t.Fatalf("want %s, got %s (%v)", want, got, err)
+		}
+	})
+
+	t.Run("custom parser", func(t *testing.T) {
+		got, err := router.ParamAs(ctx, "id", func(s string) ([]byte, error) {
+			return []byte(s), nil
+		})
+		if err != nil || string(got) != "42" {
+			t.Fatalf("want 42, got %s (%v)", got, err)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		get        func() error
+		wantStatus int
+	}{
+		{name: "invalid int", get: func() error { _, err := router.ParamInt(ctx, "bad"); return err }, wantStatus: http.StatusBadRequest},
+		{name: "invalid uuid", get: func() error { _, err := router.ParamUUID(ctx, "id"); return err }, wantStatus: http.StatusBadRequest},
+		{name: "missing param", get: func() error { _, err := router.ParamInt(ctx, "missing"); return err }, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var he *types.HTTPError
+			if err := tt.get(); !errors.As(err, &he) {
+				t.Fatalf("want *types.HTTPError, got %v", err)
+			}
+			if he.Status != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, he.Status)
+			}
+		})
+	}
+}
+
+func TestRouter_ParamConstraints(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users/:id<int>").GET(NewTestHandler(http.StatusOK, "by id"))
+	r.Prefix("/users/:name").GET(NewTestHandler(http.StatusOK, "by name"))
+	r.Prefix("/orders/:id<uuid>").GET(NewTestHandler(http.StatusOK, "order"))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/users/42", wantStatus: http.StatusOK, wantBody: "by id"},
+		{path: "/users/alice", wantStatus: http.StatusOK, wantBody: "by name"},
+		{path: "/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301", wantStatus: http.StatusOK, wantBody: "order"},
+		{path: "/orders/42", wantStatus: http.StatusNotFound, wantBody: "Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus || rr.Body.String() != tt.wantBody {
+				t.Fatalf("want %d %q, got %d %q", tt.wantStatus, tt.wantBody, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"path"
+	"strings"
+)
+
+// TrailingSlash selects how the router treats a trailing slash in the request path.
+type TrailingSlash int
+
+const (
+	// TrailingSlashLenient ignores trailing and repeated slashes, so "/users/", "//users"
+	// and "/users" all match the route registered as "/users". This is the default.
+	TrailingSlashLenient TrailingSlash = iota
+
+	// TrailingSlashStrict matches paths exactly as registered: "/users/" and "/users"
+	// are different routes, and a request for one does not match the other.
+	TrailingSlashStrict
+
+	// TrailingSlashRedirect matches paths exactly as registered, and redirects a request
+	// whose path only differs from a route by a trailing slash to the registered path.
+	TrailingSlashRedirect
+)
+
+// WithTrailingSlash sets the trailing slash policy of the router.
+// If not specified, TrailingSlashLenient is used.
+func WithTrailingSlash(policy TrailingSlash) Option {
+	return func(r *Router) {
+		r.trailingSlash = policy
+	}
+}
+
+// WithCleanPathRedirect makes the router redirect requests whose path is not clean,
+// i.e. contains repeated slashes or "." and ".." elements, to the cleaned path when a
+// route matches it. The trailing slash is kept and handled by the trailing slash policy.
+func WithCleanPathRedirect() Option {
+	return func(r *Router) {
+		r.redirectCleanPath = true
+	}
+}
+
+// WithRedirectFixedPath makes the router redirect a request that matches no route to the
+// registered spelling of its path when a route matches it case-insensitively, e.g.
+// "/API/Users/Bob" to "/api/users/Bob". Parameter and wildcard values keep their case.
+// The path is cleaned first if WithCleanPathRedirect is also set, and its trailing
+// slash fixed under TrailingSlashRedirect.
+func WithRedirectFixedPath() Option {
+	return func(r *Router) {
+		r.redirectFixedPath = true
+	}
+}
+
+// cleanRedirect returns the cleaned form of p if p is not clean and a route matches it.
+// The trailing slash policy is applied to the cleaned path too, so that a client is
+// redirected once rather than twice.
+func (r *Router) cleanRedirect(p string) (string, bool) {
+	if !r.redirectCleanPath {
+		return "", false
+	}
+
+	cp := canonicalPath(p)
+	if cp == p {
+		return "", false
+	}
+	if len(r.radix.Allowed(cp)) > 0 {
+		return cp, true
+	}
+	return r.slashRedirect(cp)
+}
+
+// slashRedirect returns p with its trailing slash added or removed if a route matches
+// that path and the trailing slash policy asks for a redirect.
+func (r *Router) slashRedirect(p string) (string, bool) {
+	if r.trailingSlash != TrailingSlashRedirect || p == "/" {
+		return "", false
+	}
+
+	alt := toggleSlash(p)
+	if len(r.radix.Allowed(alt)) == 0 {
+		return "", false
+	}
+	return alt, true
+}
+
+// fixedRedirect returns the registered spelling of p if a route matches p case-insensitively.
+func (r *Router) fixedRedirect(p string) (string, bool) {
+	if !r.redirectFixedPath {
+		return "", false
+	}
+
+	orig := p
+	if r.redirectCleanPath {
+		p = canonicalPath(p)
+	}
+
+	fixed, ok := r.radix.LookupFold(p)
+	if !ok && r.trailingSlash == TrailingSlashRedirect && p != "/" {
+		fixed, ok = r.radix.LookupFold(toggleSlash(p))
+	}
+	// never redirect a request to its own path
+	if !ok || fixed == orig {
+		return "", false
+	}
+	return fixed, true
+}
+
+// toggleSlash removes the trailing slash of p, or adds one if it has none.
+func toggleSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// canonicalPath returns the canonical form of p: rooted, without repeated slashes or
+// "." and ".." elements, and keeping a trailing slash if p had one.
+func canonicalPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+
+	cp := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cp != "/" {
+		cp += "/"
+	}
+	return cp
+}
@@ -0,0 +1,162 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+)
+
+func TestRouter_TrailingSlash(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           router.TrailingSlash
+		method           string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "lenient exact", policy: router.TrailingSlashLenient, method: http.MethodGet, path: "/users", expectedStatus: http.StatusOK},
+		{name: "lenient extra slash", policy: router.TrailingSlashLenient, method: http.MethodGet, path: "/users/", expectedStatus: http.StatusOK},
+		{name: "lenient missing slash", policy: router.TrailingSlashLenient, method: http.MethodGet, path: "/posts", expectedStatus: http.StatusOK},
+		{name: "lenient repeated slash", policy: router.TrailingSlashLenient, method: http.MethodGet, path: "//users", expectedStatus: http.StatusOK},
+
+		{name: "strict exact", policy: router.TrailingSlashStrict, method: http.MethodGet, path: "/users", expectedStatus: http.StatusOK},
+		{name: "strict extra slash", policy: router.TrailingSlashStrict, method: http.MethodGet, path: "/users/", expectedStatus: http.StatusNotFound},
+		{name: "strict registered slash", policy: router.TrailingSlashStrict, method: http.MethodGet, path: "/posts/", expectedStatus: http.StatusOK},
+		{name: "strict missing slash", policy: router.TrailingSlashStrict, method: http.MethodGet, path: "/posts", expectedStatus: http.StatusNotFound},
+		{name: "strict repeated slash", policy: router.TrailingSlashStrict, method: http.MethodGet, path: "//users", expectedStatus: http.StatusNotFound},
+
+		{name: "redirect exact", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/users", expectedStatus: http.StatusOK},
+		{name: "redirect remove slash", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/users/", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users"},
+		{name: "redirect add slash", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/posts", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/posts/"},
+		{name: "redirect keeps query", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/users/?page=2", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users?page=2"},
+		{name: "redirect head", policy: router.TrailingSlashRedirect, method: http.MethodHead, path: "/users/", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users"},
+		{name: "redirect post", policy: router.TrailingSlashRedirect, method: http.MethodPost, path: "/users/", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/users"},
+		{name: "redirect param route", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/users/42/", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users/42"},
+		{name: "redirect no match", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "/missing/", expectedStatus: http.StatusNotFound},
+		{name: "redirect wrong method", policy: router.TrailingSlashRedirect, method: http.MethodDelete, path: "/users", expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := router.New(router.WithTrailingSlash(tt.policy))
+			r.Prefix("/users").GET(testHandler)
+			r.Prefix("/users").POST(testHandler)
+			r.Prefix("/users/:id").GET(testHandler)
+			r.Prefix("/posts/").GET(testHandler)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Fatalf("expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
+
+func TestRouter_CleanPathRedirect(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           router.TrailingSlash
+		method           string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "clean path", method: http.MethodGet, path: "/users/42", expectedStatus: http.StatusOK},
+		{name: "repeated slashes", method: http.MethodGet, path: "//users///42", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users/42"},
+		{name: "dot elements", method: http.MethodGet, path: "/users/./posts/../42", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users/42"},
+		{name: "escapes location", method: http.MethodGet, path: "/users//a%20b", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users/a%20b"},
+		{name: "post uses 308", method: http.MethodPost, path: "//users", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/users"},
+		{name: "no route at cleaned path", method: http.MethodGet, path: "/missing/../nothing", expectedStatus: http.StatusNotFound},
+		{name: "lenient keeps trailing slash", method: http.MethodGet, path: "/users/", expectedStatus: http.StatusOK},
+		{name: "cleaned then slash redirect", policy: router.TrailingSlashRedirect, method: http.MethodGet, path: "//users/", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := router.New(router.WithCleanPathRedirect(), router.WithTrailingSlash(tt.policy))
+			r.Prefix("/users").GET(testHandler)
+			r.Prefix("/users").POST(testHandler)
+			r.Prefix("/users/:id").GET(testHandler)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Fatalf("expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
+
+func TestRouter_RedirectFixedPath(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []router.Option
+		method           string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "exact match", method: http.MethodGet, path: "/api/v1/users", expectedStatus: http.StatusOK},
+		{name: "static case", method: http.MethodGet, path: "/API/V1/Users", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/api/v1/users"},
+		{name: "param keeps case", method: http.MethodGet, path: "/Api/V1/Users/Bob", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/api/v1/users/Bob"},
+		{name: "keeps query", method: http.MethodGet, path: "/API/V1/USERS?page=2", expectedStatus: http.StatusMovedPermanently, expectedLocation: "/api/v1/users?page=2"},
+		{name: "post uses 308", method: http.MethodPost, path: "/API/V1/USERS", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/api/v1/users"},
+		{name: "no match", method: http.MethodGet, path: "/API/V2/Users", expectedStatus: http.StatusNotFound},
+		{name: "disabled", opts: []router.Option{}, method: http.MethodGet, path: "/API/V1/Users", expectedStatus: http.StatusNotFound},
+		{
+			name:             "cleaned and case fixed",
+			opts:             []router.Option{router.WithRedirectFixedPath(), router.WithCleanPathRedirect()},
+			method:           http.MethodGet,
+			path:             "/API//V1/./Users",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/api/v1/users",
+		},
+		{
+			name:             "case and trailing slash fixed",
+			opts:             []router.Option{router.WithRedirectFixedPath(), router.WithTrailingSlash(router.TrailingSlashRedirect)},
+			method:           http.MethodGet,
+			path:             "/API/V1/Users/",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/api/v1/users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts == nil {
+				opts = []router.Option{router.WithRedirectFixedPath()}
+			}
+
+			r, _ := router.New(opts...)
+			r.Prefix("/api/v1/users").GET(testHandler)
+			r.Prefix("/api/v1/users").POST(testHandler)
+			r.Prefix("/api/v1/users/:name").GET(testHandler)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Fatalf("expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/elmq0022/kami/types"
+)
+
+// RequestIDHeader is the default header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the incoming request IDs that are accepted.
+const maxRequestIDLength = 128
+
+// RequestIDConfig configures the RequestIDMiddleware.
+type RequestIDConfig struct {
+	// Header is the request and response header carrying the ID. Defaults to RequestIDHeader.
+	Header string
+
+	// Generate returns a new request ID. Defaults to 16 random bytes, hex encoded.
+	Generate func() string
+}
+
+// RequestIDMiddleware returns a middleware that gives every request a correlation ID.
+// The ID is read from the request header, or generated if the header is missing or
+// not a printable ASCII string of at most 128 bytes. It is stored in the request
+// context, where RequestID returns it, and echoed on the response. A generated ID is
+// also set on the header of a copy of the request passed on, so that handlers can
+// forward it; the request the middleware was called with is not modified.
+// Pass it to WithGlobalMiddleware so that requests matching no route get an ID too.
+func RequestIDMiddleware(cfg RequestIDConfig) types.Middleware {
+	if cfg.Header == "" {
+		cfg.Header = RequestIDHeader
+	}
+	if cfg.Generate == nil {
+		cfg.Generate = newRequestID
+	}
+
+	return func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			id := req.Header.Get(cfg.Header)
+			generated := !validRequestID(id)
+			if generated {
+				id = cfg.Generate()
+			}
+
+			if st := stateFrom(req.Context()); st != nil {
+				// stored in the router's state, so that the request the router recovers
+				// panics with and ends its span with sees it too
+				st.requestID = id
+			} else {
+				req = req.WithContext(types.WithRequestID(req.Context(), id))
+			}
+
+			if generated {
+				// the caller's request and its headers must not be modified
+				req = req.Clone(req.Context())
+				req.Header.Set(cfg.Header, id)
+			}
+
+			return &requestIDResponder{inner: next(req), header: cfg.Header, id: id}
+		}
+	}
+}
+
+type requestIDResponder struct {
+	inner  types.Responder
+	header string
+	id     string
+}
+
+func (r *requestIDResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(r.header, r.id)
+	r.inner.Respond(w, req)
+}
+
+// RequestID returns the ID given to the request by RequestIDMiddleware, or "" if none.
+// It is equivalent to types.RequestID, which packages the router depends on can use.
+func RequestID(ctx context.Context) string {
+	return types.RequestID(ctx)
+}
+
+// WithRequestID adds a request ID to the context, keeping any URL parameters already stored.
+// The router stores the ID itself when RequestIDMiddleware is used; this is useful for
+// testing handlers.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return types.WithRequestID(ctx, id)
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func echoRequestID(req *http.Request) types.Responder {
+	return &testResponder{Status: http.StatusOK, Body: router.RequestID(req.Context())}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "generated when missing"},
+		{name: "incoming id kept", incoming: "abc-123", keep: true},
+		{name: "id with spaces replaced", incoming: "abc 123"},
+		{name: "overlong id replaced", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seenHeader string
+			r, _ := router.New(router.WithGlobalMiddleware(router.RequestIDMiddleware(router.RequestIDConfig{})))
+			r.Prefix("/id").GET(func(req *http.Request) types.Responder {
+				seenHeader = req.Header.Get("X-Request-ID")
+				return echoRequestID(req)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/id", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			id := rr.Header().Get("X-Request-ID")
+			if tt.keep && id != tt.incoming {
+				t.Fatalf("want incoming id %q, got %q", tt.incoming, id)
+			}
+			if !tt.keep && !generated.MatchString(id) {
+				t.Fatalf("want generated id, got %q", id)
+			}
+			if rr.Body.String() != id {
+				t.Fatalf("handler saw %q, response has %q", rr.Body.String(), id)
+			}
+			if seenHeader != id {
+				t.Fatalf("want id set on the handler's request header, got %q", seenHeader)
+			}
+			// the caller's request is left alone
+			if got := req.Header.Get("X-Request-ID"); got != tt.incoming {
+				t.Fatalf("want caller's header %q kept, got %q", tt.incoming, got)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware_Config(t *testing.T) {
+	r, _ := router.New(router.WithGlobalMiddleware(router.RequestIDMiddleware(router.RequestIDConfig{
+		Header:   "X-Correlation-ID",
+		Generate: func() string { return "fixed" },
+	})))
+	r.Prefix("/id").GET(echoRequestID)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "routed", path: "/id", wantStatus: http.StatusOK},
+		{name: "not found", path: "/missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if got := rr.Header().Get("X-Correlation-ID"); got != "fixed" {
+				t.Fatalf("want X-Correlation-ID fixed, got %q", got)
+			}
+			if got := rr.Header().Get("X-Request-ID"); got != "" {
+				t.Fatalf("want no X-Request-ID, got %q", got)
+			}
+		})
+	}
+}
+
+// requestIDEcho sets the X-Request-ID response header before writing inner.
+type requestIDEcho struct {
+	inner types.Responder
+	id    string
+}
+
+func (e *requestIDEcho) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Request-ID", e.id)
+	e.inner.Respond(w, req)
+}
+
+func TestRequestIDMiddleware_Panic(t *testing.T) {
+	var seen string
+	r, _ := router.New(
+		router.WithGlobalMiddleware(router.RequestIDMiddleware(router.RequestIDConfig{})),
+		router.WithPanicHandler(func(req *http.Request, recovered any, stack []byte) types.Responder {
+			seen = router.RequestID(req.Context())
+			// global middleware does not wrap the panic response, so the handler echoes the ID
+			return &requestIDEcho{inner: NewTestHandler(http.StatusInternalServerError, "panic")(req), id: seen}
+		}),
+	)
+	r.Prefix("/panic").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("want status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+	if seen != "abc-123" {
+		t.Fatalf("want panic handler to see abc-123, got %q", seen)
+	}
+	if got := rr.Header().Get("X-Request-ID"); got != "abc-123" {
+		t.Fatalf("want X-Request-ID on the panic response, got %q", got)
+	}
+}
+
+func TestRequestIDMiddleware_DerivedContexts(t *testing.T) {
+	type key struct{}
+
+	// an outer middleware derives a context that a goroutine uses while routing continues
+	watch := func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+
+			started, stop, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = ctx.Value(key{})
+				close(started)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						// a missing key is looked up through every context down to the request's
+						_ = ctx.Value(key{})
+					}
+				}
+			}()
+
+			<-started
+			responder := next(req.WithContext(ctx))
+			close(stop)
+			<-done
+			return responder
+		}
+	}
+
+	r, _ := router.New(router.WithGlobalMiddleware(watch, router.RequestIDMiddleware(router.RequestIDConfig{})))
+	r.Prefix("/id").GET(echoRequestID)
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Body.String() != "abc-123" {
+		t.Fatalf("want handler to see abc-123, got %q", rr.Body.String())
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := router.WithParams(context.Background(), map[string]string{"id": "42"})
+	ctx = router.WithRequestID(ctx, "abc-123")
+	ctx = router.WithParams(ctx, map[string]string{"id": "43"})
+
+	if got := router.RequestID(ctx); got != "abc-123" {
+		t.Fatalf("want request id abc-123, got %q", got)
+	}
+	if got := router.Param(ctx, "id"); got != "43" {
+		t.Fatalf("want param 43, got %q", got)
+	}
+	if got := router.Param(router.WithRequestID(ctx, "def"), "id"); got != "43" {
+		t.Fatalf("want params kept by WithRequestID, got %q", got)
+	}
+	if got := router.RequestID(context.Background()); got != "" {
+		t.Fatalf("want empty request id, got %q", got)
+	}
+}
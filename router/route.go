@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+// RouteOption configures a single route at registration, e.g.
+// r.Prefix("/admin").GET(h, router.Middleware(auth), router.Named("admin")).
+type RouteOption func(rc *routeConfig)
+
+type routeConfig struct {
+	name       string
+	meta       *types.RouteMeta
+	timeout    time.Duration
+	middleware []types.Middleware
+}
+
+// Named gives the route a name, so that its URL can be built with URL.
+// It is equivalent to registering the route on r.Name(name).
+func Named(name string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.name = name
+	}
+}
+
+// Meta documents the route for API description generators such as the openapi package.
+// It is equivalent to registering the route on r.Describe(meta).
+func Meta(meta types.RouteMeta) RouteOption {
+	return func(rc *routeConfig) {
+		rc.meta = &meta
+	}
+}
+
+// Middleware applies middleware to this route only, inside the middleware added with Use.
+// Middleware is applied in the order given, and multiple Middleware options append to the chain.
+func Middleware(mws ...types.Middleware) RouteOption {
+	return func(rc *routeConfig) {
+		rc.middleware = append(rc.middleware, mws...)
+	}
+}
+
+// Timeout sets a deadline of d on the request context seen by the route's handler and
+// route middleware. The handler is not interrupted, so it should stop work when
+// req.Context() is done; if the deadline has passed when it returns, its response is
+// replaced with a 503 Service Unavailable problem.
+func Timeout(d time.Duration) RouteOption {
+	return func(rc *routeConfig) {
+		rc.timeout = d
+	}
+}
+
+// routeConfig returns the configuration of a route registered on r with opts.
+func (r *Router) routeConfig(opts []RouteOption) routeConfig {
+	rc := routeConfig{name: r.name, meta: r.meta}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	return rc
+}
+
+// timeoutMiddleware bounds the handler's request context by d. The context is only
+// cancelled once the response is written, since responders may still use it. A nil
+// responder is passed through unchanged, after cancelling the context.
+func timeoutMiddleware(d time.Duration) types.Middleware {
+	return func(next types.Handler) types.Handler {
+		return func(req *http.Request) types.Responder {
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+
+			responder := next(req.WithContext(ctx))
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				cancel()
+				return responders.JSONErrorResponse("request timed out", http.StatusServiceUnavailable)
+			}
+			if responder == nil {
+				cancel()
+				return nil
+			}
+			return &cancelResponder{inner: responder, cancel: cancel}
+		}
+	}
+}
+
+// cancelResponder releases a context once its inner responder has written the response.
+type cancelResponder struct {
+	inner  types.Responder
+	cancel context.CancelFunc
+}
+
+func (c *cancelResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	defer c.cancel()
+	c.inner.Respond(w, req)
+}
@@ -0,0 +1,138 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func TestRouteOptions_Middleware(t *testing.T) {
+	r, _ := router.New()
+	r = r.Use(testMiddleware1)
+	r.Prefix("/admin").GET(testHandler, router.Middleware(testMiddleware2), router.Middleware(testMiddleware3))
+	r.Prefix("/public").GET(testHandler)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		// route middleware runs inside the router's, in the order given
+		{path: "/admin", want: "321"},
+		{path: "/public", want: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if got := rr.Body.String(); got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	routes := r.Routes()
+	if routes[0].Path != "/admin" || routes[0].Middleware != 3 {
+		t.Fatalf("want /admin with 3 middleware, got %+v", routes[0])
+	}
+}
+
+func TestRouteOptions_NamedAndMeta(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users/:id").GET(testHandler, router.Named("user"), router.Meta(types.RouteMeta{Summary: "Get a user"}))
+	r.Prefix("/users/:id").Name("ignored").DELETE(testHandler, router.Named("deleteUser"))
+
+	url, err := r.URL("user", "id", "42")
+	if err != nil || url != "/users/42" {
+		t.Fatalf("want /users/42, got %q (%v)", url, err)
+	}
+	if _, err := r.URL("ignored"); err == nil {
+		t.Fatal("route option should override the name given with Name")
+	}
+
+	routes := r.Routes()
+	if routes[0].Method != http.MethodDelete || routes[0].Name != "deleteUser" || routes[0].Meta != nil {
+		t.Fatalf("unexpected DELETE route: %+v", routes[0])
+	}
+	if routes[1].Name != "user" || routes[1].Meta == nil || routes[1].Meta.Summary != "Get a user" {
+		t.Fatalf("unexpected GET route: %+v", routes[1])
+	}
+}
+
+func TestRouteOptions_Timeout(t *testing.T) {
+	slow := func(req *http.Request) types.Responder {
+		<-req.Context().Done()
+		return &testResponder{Status: http.StatusOK, Body: "slow"}
+	}
+	fast := func(req *http.Request) types.Responder {
+		if _, ok := req.Context().Deadline(); !ok {
+			return &testResponder{Status: http.StatusInternalServerError, Body: "no deadline"}
+		}
+		// params stay reachable through the derived context
+		return &testResponder{Status: http.StatusOK, Body: router.Param(req.Context(), "id")}
+	}
+
+	r, _ := router.New()
+	r.Prefix("/slow").GET(slow, router.Timeout(10*time.Millisecond))
+	r.Prefix("/fast/:id").GET(fast, router.Timeout(time.Second))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/slow", wantStatus: http.StatusServiceUnavailable},
+		{path: "/fast/42", wantStatus: http.StatusOK, wantBody: "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Fatalf("want body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteOptions_TimeoutNilResponder(t *testing.T) {
+	var ctx context.Context
+	handler := func(req *http.Request) types.Responder {
+		ctx = req.Context()
+		return nil
+	}
+	quiet := func(req *http.Request, recovered any, stack []byte) types.Responder {
+		return &testResponder{Status: http.StatusInternalServerError}
+	}
+
+	r, _ := router.New(router.WithPanicHandler(quiet))
+	r.Prefix("/plain").GET(handler)
+	r.Prefix("/timeout").GET(handler, router.Timeout(time.Second))
+
+	plain := httptest.NewRecorder()
+	r.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/plain", nil))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/timeout", nil))
+
+	// a nil responder is treated the same with or without a timeout
+	if rr.Code != plain.Code {
+		t.Fatalf("want status %d, got %d", plain.Code, rr.Code)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected the timeout context to be cancelled")
+	}
+}
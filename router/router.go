@@ -3,186 +3,424 @@
 package router
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync/atomic"
+	"syscall"
 
 	"github.com/elmq0022/kami/handlers"
 	"github.com/elmq0022/kami/internal/radix"
 	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/tracing"
 	"github.com/elmq0022/kami/types"
 )
 
 // Router is the main HTTP router that uses a radix tree for efficient route matching.
-// It supports middleware, custom 404 handlers, and panic recovery.
+// It supports middleware, custom 404 and 405 handlers, and configurable panic recovery.
 type Router struct {
-	radix      *radix.Radix
-	notFound   types.Handler
-	middleware []types.Middleware
-	started    *atomic.Bool
-	prefix     string
+	radix            *radix.Radix
+	notFound         types.Handler
+	methodNotAllowed types.Handler
+	panicHandler     types.PanicHandler
+	cors             *CORSConfig
+	errorMappings    []errorMapping
+	middleware       []types.Middleware
+	global           []types.Middleware
+	dispatch         types.Handler
+	started          *atomic.Bool
+	prefix           string
+	name             string
+	meta             *types.RouteMeta
+	names            map[string]string
+	server           serverConfig
+	tracer           *tracing.Tracer
+
+	trailingSlash     TrailingSlash
+	redirectCleanPath bool
+	redirectFixedPath bool
 }
 
 // New creates a new Router with the given options.
 // Options can configure middleware, custom 404 handlers, and other router behavior.
 // Returns an error if the underlying radix tree initialization fails.
 func New(opts ...Option) (*Router, error) {
-	rdx, err := radix.New()
-	if err != nil {
-		return nil, err
-	}
-
 	r := &Router{
-		radix:    rdx,
-		notFound: handlers.DefaultNotFoundHandler,
-		started:  &atomic.Bool{},
+		notFound:         handlers.DefaultNotFoundHandler,
+		methodNotAllowed: handlers.DefaultMethodNotAllowedHandler,
+		panicHandler:     handlers.DefaultPanicHandler,
+		started:          &atomic.Bool{},
+		names:            make(map[string]string),
 	}
 
 	for _, opt := range opts {
 		opt(r)
 	}
 
+	// the tree only normalizes paths under the lenient trailing slash policy
+	newRadix := radix.New
+	if r.trailingSlash != TrailingSlashLenient {
+		newRadix = radix.NewStrict
+	}
+
+	rdx, err := newRadix()
+	if err != nil {
+		return nil, err
+	}
+	r.radix = rdx
+
+	// global middleware wraps routing itself, in the order registered
+	r.dispatch = r.route
+	for i := len(r.global) - 1; i >= 0; i-- {
+		r.dispatch = r.global[i](r.dispatch)
+	}
+
 	return r, nil
 }
 
 // Run starts the HTTP server on the specified port.
 // The port should be in the format ":8080" or "localhost:8080".
-// This is a convenience method that calls http.ListenAndServe with the router as the handler.
-// The function will block until the server fails to start or is shut down.
+// This is a convenience method that calls RunContext and shuts the server down
+// gracefully on SIGINT or SIGTERM.
+// The function will block until the server is shut down, and exits the process if it fails.
 func (r *Router) Run(port string) {
-	r.started.Store(true)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Printf("Starting server on %s", port)
-	if err := http.ListenAndServe(port, r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	if err := r.RunContext(ctx, port); err != nil {
+		log.Fatalf("Server failed: %v", err)
 	}
 }
 
 // ServeHTTP implements http.Handler, making Router compatible with the standard library.
-// It performs route lookup, applies middleware, handles panics, and executes the matched handler.
+// It runs the global middleware set with WithGlobalMiddleware around route lookup, applies the
+// route's middleware, handles panics, and executes the matched handler.
+// Panics are passed to the configured panic handler (defaults to a JSON 500 response), whose
+// response replaces the headers already set, including those set by global middleware, which
+// does not wrap the panic handler's response. If the response was already started when the panic
+// occurred, the panic handler is still called, but ServeHTTP then panics with http.ErrAbortHandler
+// so that net/http aborts the connection instead of completing a truncated response.
 // If no route matches, the configured notFound handler is used (defaults to a 404 response).
+// If the path matches but the method does not, the configured methodNotAllowed handler is used
+// (defaults to a 405 response) and the Allow header lists the methods registered for the path.
+// OPTIONS requests to paths without an explicit OPTIONS route are answered automatically,
+// including CORS preflight requests when a policy is configured with WithCORS.
+// HEAD requests to paths without an explicit HEAD route run the GET handler with the body discarded.
+// Requests for unclean paths or paths differing from a route by a trailing slash are redirected
+// when enabled with WithCleanPathRedirect and WithTrailingSlash, and paths matching a route only
+// case-insensitively when enabled with WithRedirectFixedPath.
+// With WithTracer, every request is covered by a server span named after the matched route.
+// The objects the router uses while serving a request are pooled and recycled once the response is
+// written; the request context, including the URL parameters, is not, and stays valid afterwards.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.started.Store(true)
 
+	var span *tracing.Span
+	if r.tracer != nil {
+		req, span = r.startSpan(req)
+	}
+
+	st := newRequestState(req.Context())
+	st.scratch = acquireScratch()
+	defer st.release()
+	req = req.WithContext(st)
+
+	tw := &st.scratch.writer
+	tw.ResponseWriter = w
+	w = tw
+
+	// the span ends after the panic handler below has responded, or the response was aborted
+	if span != nil {
+		defer func() {
+			aborted := recover()
+			endSpan(span, st, aborted)
+			if aborted != nil {
+				panic(aborted)
+			}
+		}()
+	}
+
 	defer func() {
-		if err := recover(); err != nil {
-			log.Printf("panic handling %s %s: %v", req.Method, req.URL.Path, err)
-			http.Error(
-				w,
-				http.StatusText(http.StatusInternalServerError),
-				http.StatusInternalServerError,
-			)
+		if rec := recover(); rec != nil {
+			st.scratch.recovered = rec
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			responder := r.panicHandler(req, rec, debug.Stack())
+
+			// The responder already started the response and a second status line cannot be
+			// sent; abort the connection so the client does not take a truncated body as complete
+			if tw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			// drop the headers of the failed response, such as Allow and the CORS headers
+			clear(w.Header())
+			responder.Respond(w, req)
 		}
 	}()
 
-	h, params, ok := r.radix.Lookup(req.Method, req.URL.Path)
-	if !ok {
+	responder := r.dispatch(req)
+	responder.Respond(w, req)
+}
+
+// route is the innermost handler of the global middleware chain. It looks up the route
+// for req and runs its handler, returning a responder that also writes the headers the
+// router adds, such as Allow and the CORS headers.
+func (r *Router) route(req *http.Request) types.Responder {
+	st := stateFrom(req.Context())
+	if st == nil {
+		// a global middleware replaced the request context
+		st = newRequestState(req.Context())
+		req = req.WithContext(st)
+	}
+
+	if target, ok := r.cleanRedirect(req.URL.Path); ok {
+		return responders.PermanentRedirectResponse(req, target)
+	}
+
+	route, ok := r.radix.Lookup(req.Method, req.URL.Path, &st.params)
+
+	// the pooled responder serves a single dispatch; a global middleware calling next
+	// again for the same request gets its own
+	var rr *routedResponder
+	if st.scratch != nil && st.scratch.routed.router == nil {
+		rr = &st.scratch.routed
+	} else {
+		rr = new(routedResponder)
+	}
+	rr.router = r
+	rr.req = req
+
+	// Fall back to the GET handler for HEAD requests, discarding the body
+	if !ok && req.Method == http.MethodHead {
+		route, ok = r.radix.Lookup(http.MethodGet, req.URL.Path, &st.params)
+		rr.head = ok
+	}
+
+	h := route.Handler
+	if ok {
+		st.pattern = route.Path
+		st.tree = r.radix
+	} else {
 		h = r.notFound
-		params = map[string]string{}
 	}
 
-	ctx := WithParams(req.Context(), params)
-	req = req.WithContext(ctx)
+	if !ok || isPreflight(req) {
+		rr.allowed = r.appendAllowed(rr.allowedBuf[:0], req.URL.Path)
+	}
 
-	responder := h(req)
-	responder.Respond(w, req)
+	if !ok && len(rr.allowed) == 0 {
+		if target, found := r.slashRedirect(req.URL.Path); found {
+			return responders.PermanentRedirectResponse(req, target)
+		}
+		if target, found := r.fixedRedirect(req.URL.Path); found {
+			return responders.PermanentRedirectResponse(req, target)
+		}
+	}
+
+	if !ok && len(rr.allowed) > 0 {
+		rr.setAllow = true
+		if req.Method == http.MethodOptions {
+			h = handlers.DefaultOptionsHandler
+		} else {
+			h = r.methodNotAllowed
+		}
+	}
+
+	rr.inner = h(req)
+	return rr
+}
+
+// routedResponder writes the response of a routed request. The first one routed for a request
+// lives in the pooled scratch of the request, so routing a request does not allocate, and is
+// only valid until ServeHTTP returns.
+type routedResponder struct {
+	router   *Router
+	inner    types.Responder
+	req      *http.Request
+	allowed  []string
+	setAllow bool
+	head     bool
+
+	// allowedBuf backs allowed for paths with up to 12 methods, so that computing
+	// them, as every unmatched request does, does not allocate
+	allowedBuf [12]string
 }
 
-func (r *Router) add(method string, handler types.Handler) {
+// Respond writes the router's headers and then the handler's response, using the request
+// the handler saw rather than req, which global middleware may not have rewritten.
+func (rr *routedResponder) Respond(w http.ResponseWriter, _ *http.Request) {
+	r, req := rr.router, rr.req
+
+	if rr.setAllow {
+		w.Header().Set("Allow", strings.Join(rr.allowed, ", "))
+	}
+
+	if r.cors != nil {
+		if isPreflight(req) && len(rr.allowed) > 0 {
+			r.cors.preflight(w, req, rr.allowed)
+		} else {
+			r.cors.decorate(w, req)
+		}
+	}
+
+	if rr.head {
+		hw := &headResponseWriter{ResponseWriter: w}
+		rr.inner.Respond(hw, req)
+		hw.finish()
+		return
+	}
+	rr.inner.Respond(w, req)
+}
+
+// appendAllowed appends the methods accepted for path to methods, including the OPTIONS and
+// HEAD methods the router answers automatically, and returns the sorted result.
+// Returns methods unchanged if no route matches path.
+func (r *Router) appendAllowed(methods []string, path string) []string {
+	methods = r.radix.AppendAllowed(methods, path)
+	if len(methods) == 0 {
+		return methods
+	}
+
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+func (r *Router) add(method string, handler types.Handler, opts []RouteOption) {
 	if r.started.Load() {
 		panic(fmt.Sprintf("cannot register path: %s since the router is running", r.prefix))
 	}
 
-	// Apply route-specific middleware in reverse order at registration time
+	rc := r.routeConfig(opts)
+
+	// Wrap the handler in the route's own middleware and timeout first
 	h := handler
+	for i := len(rc.middleware) - 1; i >= 0; i-- {
+		h = rc.middleware[i](h)
+	}
+	if rc.timeout > 0 {
+		h = timeoutMiddleware(rc.timeout)(h)
+	}
+
+	// Apply the router's middleware outermost, in reverse order at registration time
 	for i := len(r.middleware) - 1; i >= 0; i-- {
 		h = r.middleware[i](h)
 	}
 
-	if err := r.radix.AddRoute(method, r.prefix, h); err != nil {
+	route := types.Route{
+		Method:     method,
+		Path:       r.prefix,
+		Name:       rc.name,
+		Middleware: len(r.middleware) + len(rc.middleware),
+		Meta:       rc.meta,
+		Handler:    h,
+	}
+	if err := r.radix.Insert(route); err != nil {
 		panic(fmt.Sprintf("%s %s: %v", method, r.prefix, err))
 	}
+
+	r.registerName(rc.name)
 }
 
 // GET registers a handler for GET requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) GET(handler types.Handler) {
-	r.add(http.MethodGet, handler)
+func (r *Router) GET(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodGet, handler, opts)
 }
 
 // POST registers a handler for POST requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) POST(handler types.Handler) {
-	r.add(http.MethodPost, handler)
+func (r *Router) POST(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodPost, handler, opts)
 }
 
 // PUT registers a handler for PUT requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) PUT(handler types.Handler) {
-	r.add(http.MethodPut, handler)
+func (r *Router) PUT(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodPut, handler, opts)
 }
 
 // DELETE registers a handler for DELETE requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) DELETE(handler types.Handler) {
-	r.add(http.MethodDelete, handler)
+func (r *Router) DELETE(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodDelete, handler, opts)
 }
 
 // PATCH registers a handler for PATCH requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) PATCH(handler types.Handler) {
-	r.add(http.MethodPatch, handler)
+func (r *Router) PATCH(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodPatch, handler, opts)
 }
 
 // HEAD registers a handler for HEAD requests at the router's current prefix path.
+// GET routes already answer HEAD requests, so this is only needed to override that behavior.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) HEAD(handler types.Handler) {
-	r.add(http.MethodHead, handler)
+func (r *Router) HEAD(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodHead, handler, opts)
 }
 
 // OPTIONS registers a handler for OPTIONS requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) OPTIONS(handler types.Handler) {
-	r.add(http.MethodOptions, handler)
+func (r *Router) OPTIONS(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodOptions, handler, opts)
 }
 
 // CONNECT registers a handler for CONNECT requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) CONNECT(handler types.Handler) {
-	r.add(http.MethodConnect, handler)
+func (r *Router) CONNECT(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodConnect, handler, opts)
 }
 
 // TRACE registers a handler for TRACE requests at the router's current prefix path.
 // The prefix can include parameters (e.g., "/users/:id") and wildcards (e.g., "/files/*filepath").
+// Route options such as Middleware, Named, Meta and Timeout configure this route only.
 // Panics if the route cannot be registered (e.g., conflicts with existing routes).
-func (r *Router) TRACE(handler types.Handler) {
-	r.add(http.MethodTrace, handler)
+func (r *Router) TRACE(handler types.Handler, opts ...RouteOption) {
+	r.add(http.MethodTrace, handler, opts)
 }
 
 func (r *Router) shallowCopy() *Router {
-	nr := Router{
-		radix:      r.radix,
-		notFound:   r.notFound,
-		prefix:     r.prefix,
-		started:    r.started,
-		middleware: append([]types.Middleware{}, r.middleware...),
-	}
+	nr := *r
+	nr.middleware = append([]types.Middleware{}, r.middleware...)
 	return &nr
 }
 
-// Use adds one or more middleware to the router's global middleware chain.
-// Middleware is applied to all routes in the order it is registered.
-// Multiple calls to Use will append middleware to the chain.
+// Use returns a copy of the router that applies mws to the handler of every route
+// registered on it, or on routers derived from it, after the middleware already added.
+// Middleware is applied at registration, so it runs only for matched routes; use
+// WithGlobalMiddleware for middleware that must also see unmatched requests.
 func (r *Router) Use(mws ...types.Middleware) *Router {
 	nr := r.shallowCopy()
 	nr.middleware = append(nr.middleware, mws...)
@@ -201,6 +439,8 @@ func (r *Router) Prefix(segment string) *Router {
 
 	nr := r.shallowCopy()
 	nr.prefix = base + "/" + seg
+	nr.name = ""
+	nr.meta = nil
 	return nr
 }
 
@@ -213,7 +453,10 @@ func (r *Router) ServeStatic(f fs.FS) {
 	staticResponder := responders.NewStaticDirResponder(f, r.prefix)
 
 	// Add wildcard pattern for file paths and register handler
-	r.Prefix("/*fp").GET(func(req *http.Request) types.Responder {
+	sr := r.Prefix("/*fp")
+	sr.name = r.name
+	sr.meta = r.meta
+	sr.GET(func(req *http.Request) types.Responder {
 		return staticResponder
 	})
 }
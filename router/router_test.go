@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/elmq0022/kami/handlers"
 	"github.com/elmq0022/kami/router"
 	"github.com/elmq0022/kami/types"
 )
@@ -44,7 +45,7 @@ func TestRouter_RoundTrip(t *testing.T) {
 		{name: "static css", method: http.MethodGet, path: "/static/*path", wantStatus: http.StatusOK, wantBody: "static", wantErr: nil, wantParams: map[string]string{"path": "css/main.css"}, callPath: "/static/css/main.css"},
 
 		// Method mismatch (should not match)
-		{name: "wrong method", method: http.MethodPost, path: "/about", wantStatus: http.StatusNotFound, wantBody: "Not Found", wantErr: nil, wantParams: map[string]string{}, callPath: "/about"},
+		{name: "wrong method", method: http.MethodPost, path: "/about", wantStatus: http.StatusMethodNotAllowed, wantBody: "Method Not Allowed", wantErr: nil, wantParams: nil, callPath: "/about"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -111,3 +112,201 @@ func TestRouter_CannotAddRoutesAfterStarted(t *testing.T) {
 
 	r.Prefix("/after").GET(NewTestHandler(http.StatusOK, "after"))
 }
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	r, err := router.New()
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	r.Prefix("/users/:id").GET(NewTestHandler(http.StatusOK, "get"))
+	r.Prefix("/users/:id").DELETE(NewTestHandler(http.StatusOK, "delete"))
+
+	t.Run("path matches but method does not", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/42", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("status: want %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+
+		want := "DELETE, GET, HEAD, OPTIONS"
+		if got := rr.Header().Get("Allow"); got != want {
+			t.Fatalf("Allow: want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("path does not match", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/posts/42", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("status: want %d, got %d", http.StatusNotFound, rr.Code)
+		}
+
+		if got := rr.Header().Get("Allow"); got != "" {
+			t.Fatalf("Allow: want empty, got %q", got)
+		}
+	})
+}
+
+type panickingResponder struct {
+	writeFirst bool
+}
+
+func (p *panickingResponder) Respond(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Partial", "true")
+	if p.writeFirst {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+	}
+	panic("responder failed")
+}
+
+func TestRouter_PanicRecovery(t *testing.T) {
+	var recovered []any
+	r, err := router.New(router.WithPanicHandler(func(req *http.Request, rec any, stack []byte) types.Responder {
+		recovered = append(recovered, rec)
+		return handlers.DefaultPanicHandler(req, rec, stack)
+	}))
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	r.Prefix("/handler").GET(func(req *http.Request) types.Responder {
+		panic("handler failed")
+	})
+	r.Prefix("/responder").GET(func(req *http.Request) types.Responder {
+		return &panickingResponder{}
+	})
+	r.Prefix("/streaming").GET(func(req *http.Request) types.Responder {
+		return &panickingResponder{writeFirst: true}
+	})
+
+	t.Run("panic before writing returns JSON 500", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/handler", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("status: want %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+
+		if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("Content-Type: want application/problem+json, got %s", ct)
+		}
+	})
+
+	t.Run("headers of the failed response are dropped", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/responder", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("status: want %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		if got := rr.Header().Get("X-Partial"); got != "" {
+			t.Fatalf("want header of the failed response dropped, got %q", got)
+		}
+	})
+
+	t.Run("panic after writing aborts the response", func(t *testing.T) {
+		recovered = nil
+		req := httptest.NewRequest(http.MethodGet, "/streaming", nil)
+		rr := httptest.NewRecorder()
+
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Fatalf("want panic %v, got %v", http.ErrAbortHandler, rec)
+			}
+			if len(recovered) != 1 || recovered[0] != "responder failed" {
+				t.Fatalf("want the panic handler called with the panic, got %v", recovered)
+			}
+			if rr.Body.String() != "partial" {
+				t.Fatalf("body: want %q, got %q", "partial", rr.Body.String())
+			}
+		}()
+		r.ServeHTTP(rr, req)
+	})
+}
+
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardWriter) WriteHeader(int)             {}
+
+type noopResponder struct{}
+
+func (noopResponder) Respond(w http.ResponseWriter, req *http.Request) {}
+
+func benchmarkServeHTTP(b *testing.B, path string, handler types.Handler) {
+	r, _ := router.New(router.WithNotFound(func(req *http.Request) types.Responder {
+		return noopResponder{}
+	}))
+	r.Prefix("/health").GET(handler)
+	r.Prefix("/api/v1/users/:id/posts/:postId").GET(handler)
+	r.Prefix("/static/*fp").GET(handler)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func TestRouter_NotFoundAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not stable under the race detector")
+	}
+
+	r, _ := router.New(router.WithNotFound(func(req *http.Request) types.Responder {
+		return noopResponder{}
+	}))
+	r.Prefix("/health").GET(func(req *http.Request) types.Responder {
+		return noopResponder{}
+	})
+
+	allocs := func(path string) float64 {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := &discardWriter{header: make(http.Header)}
+		return testing.AllocsPerRun(100, func() {
+			r.ServeHTTP(w, req)
+		})
+	}
+
+	// finding that no route matches, and so no method is allowed, does not allocate
+	if notFound, matched := allocs("/missing"), allocs("/health"); notFound > matched {
+		t.Fatalf("want a 404 to allocate no more than a matched route (%v), got %v", matched, notFound)
+	}
+}
+
+func BenchmarkRouter_ServeHTTP(b *testing.B) {
+	noop := func(req *http.Request) types.Responder {
+		return noopResponder{}
+	}
+	param := func(req *http.Request) types.Responder {
+		_ = router.Param(req.Context(), "postId")
+		return noopResponder{}
+	}
+
+	b.Run("static", func(b *testing.B) {
+		benchmarkServeHTTP(b, "/health", noop)
+	})
+	b.Run("params", func(b *testing.B) {
+		benchmarkServeHTTP(b, "/api/v1/users/42/posts/7", param)
+	})
+	b.Run("wildcard", func(b *testing.B) {
+		benchmarkServeHTTP(b, "/static/js/app.js", noop)
+	})
+	b.Run("not found", func(b *testing.B) {
+		benchmarkServeHTTP(b, "/missing", noop)
+	})
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/types"
+)
+
+// Routes returns every route registered on the router, sorted by path and then method.
+// Each route reports the full pattern it was registered with, its name, if any, and
+// the number of middleware applied to it. Routes answered automatically, such as HEAD
+// and OPTIONS, are not listed.
+func (r *Router) Routes() types.Routes {
+	return r.radix.Routes()
+}
+
+// Describe returns a copy of the router whose next registered routes carry meta,
+// documenting them for API description generators such as the openapi package.
+// Like Name, the description is not inherited by Prefix.
+func (r *Router) Describe(meta types.RouteMeta) *Router {
+	nr := r.shallowCopy()
+	nr.meta = &meta
+	return nr
+}
+
+// RoutesHandler returns a handler that renders the registered routes as a JSON array,
+// for example to check a deployment or generate documentation. It is not registered
+// automatically; mount it on a debug path with r.Prefix("/debug/routes").GET(r.RoutesHandler()).
+func (r *Router) RoutesHandler() types.Handler {
+	return func(req *http.Request) types.Responder {
+		return responders.JSONResponse(r.Routes(), http.StatusOK)
+	}
+}
@@ -0,0 +1,98 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func passThrough(next types.Handler) types.Handler {
+	return next
+}
+
+func TestRouter_Routes(t *testing.T) {
+	r, _ := router.New()
+	api := r.Prefix("/api").Use(passThrough)
+	api.Prefix("/users").Name("users").GET(testHandler)
+	api.Prefix("/users/:id").Use(passThrough).DELETE(testHandler)
+	r.Prefix("/health").GET(testHandler)
+
+	want := types.Routes{
+		{Method: http.MethodGet, Path: "/api/users", Name: "users", Middleware: 1},
+		{Method: http.MethodDelete, Path: "/api/users/:id", Middleware: 2},
+		{Method: http.MethodGet, Path: "/health"},
+	}
+
+	got := r.Routes()
+	if len(got) != len(want) {
+		t.Fatalf("want %d routes, got %d", len(want), len(got))
+	}
+	for i := range want {
+		g := got[i]
+		if g.Method != want[i].Method || g.Path != want[i].Path || g.Name != want[i].Name || g.Middleware != want[i].Middleware {
+			t.Fatalf("route %d: want %+v, got %+v", i, want[i], g)
+		}
+	}
+}
+
+func TestRouter_RoutesHandler(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users/:id").Name("user").GET(testHandler)
+	r.Prefix("/debug/routes").GET(r.RoutesHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("want Content-Type application/json, got %q", ct)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	want := []map[string]any{
+		{"method": "GET", "path": "/debug/routes", "middleware": float64(0)},
+		{"method": "GET", "path": "/users/:id", "name": "user", "middleware": float64(0)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("route %d: want %v, got %v", i, want[i], got[i])
+		}
+		for k, v := range want[i] {
+			if got[i][k] != v {
+				t.Fatalf("route %d: want %v, got %v", i, want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestRouter_Describe(t *testing.T) {
+	r, _ := router.New()
+	users := r.Prefix("/users").Describe(types.RouteMeta{Summary: "Users", Tags: []string{"users"}})
+	users.GET(testHandler)
+	users.Prefix("/:id").GET(testHandler)
+
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("want 2 routes, got %d", len(routes))
+	}
+	if routes[0].Meta == nil || routes[0].Meta.Summary != "Users" {
+		t.Fatalf("want described route, got %+v", routes[0].Meta)
+	}
+	if routes[1].Meta != nil {
+		t.Fatalf("description should not be inherited by Prefix, got %+v", routes[1].Meta)
+	}
+}
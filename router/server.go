@@ -0,0 +1,152 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultShutdownTimeout bounds how long a cancelled server waits for in-flight requests.
+const defaultShutdownTimeout = 10 * time.Second
+
+// serverConfig holds the http.Server settings applied by RunContext and Serve.
+// Zero durations leave the corresponding http.Server timeout disabled.
+type serverConfig struct {
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	tlsConfig         *tls.Config
+	certFile          string
+	keyFile           string
+}
+
+// WithReadTimeout sets the maximum duration for reading an entire request, including the body.
+func WithReadTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.server.readTimeout = d
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading request headers.
+func WithReadHeaderTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.server.readHeaderTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the maximum duration before timing out writes of the response.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.server.writeTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the maximum time to wait for the next request on a keep-alive connection.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.server.idleTimeout = d
+	}
+}
+
+// WithShutdownTimeout sets how long the server waits for in-flight requests to complete
+// after its context is cancelled. Defaults to 10 seconds.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.server.shutdownTimeout = d
+	}
+}
+
+// WithTLS serves HTTPS using the given certificate and key files.
+func WithTLS(certFile, keyFile string) Option {
+	return func(r *Router) {
+		r.server.certFile = certFile
+		r.server.keyFile = keyFile
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the server.
+// If the config provides certificates, the server serves HTTPS without WithTLS.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(r *Router) {
+		r.server.tlsConfig = cfg
+	}
+}
+
+// useTLS reports whether the configuration provides a certificate.
+func (c *serverConfig) useTLS() bool {
+	if c.certFile != "" {
+		return true
+	}
+	return c.tlsConfig != nil && (len(c.tlsConfig.Certificates) > 0 || c.tlsConfig.GetCertificate != nil)
+}
+
+func (r *Router) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           r,
+		ReadTimeout:       r.server.readTimeout,
+		ReadHeaderTimeout: r.server.readHeaderTimeout,
+		WriteTimeout:      r.server.writeTimeout,
+		IdleTimeout:       r.server.idleTimeout,
+		TLSConfig:         r.server.tlsConfig,
+	}
+}
+
+// RunContext listens on addr and serves requests until ctx is cancelled.
+// On cancellation the server stops accepting connections and waits up to the
+// shutdown timeout for in-flight requests to complete.
+// Returns nil after a clean shutdown, or the error that stopped the server.
+func (r *Router) RunContext(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln and serves requests until ctx is cancelled.
+// It behaves like RunContext for callers that manage their own listener.
+// The listener is closed when Serve returns.
+func (r *Router) Serve(ctx context.Context, ln net.Listener) error {
+	r.started.Store(true)
+
+	srv := r.newServer(ln.Addr().String())
+
+	errCh := make(chan error, 1)
+	go func() {
+		if r.server.useTLS() {
+			errCh <- srv.ServeTLS(ln, r.server.certFile, r.server.keyFile)
+		} else {
+			errCh <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := r.server.shutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/types"
+)
+
+func serve(t *testing.T, r *router.Router) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.Serve(ctx, ln)
+	}()
+
+	return "http://" + ln.Addr().String(), cancel, errCh
+}
+
+func TestServe_ShutsDownWhenContextCancelled(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/").GET(NewTestHandler(http.StatusOK, "up"))
+
+	url, cancel, errCh := serve(t, r)
+
+	resp, err := http.Get(url + "/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "up" {
+		t.Fatalf("want %q, got %q", "up", string(body))
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("want nil error after shutdown, got %v", err)
+	}
+}
+
+// closeNotifyListener closes closed once the listener has been closed, which
+// http.Server.Shutdown does before it starts waiting for in-flight requests.
+type closeNotifyListener struct {
+	net.Listener
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (l *closeNotifyListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r, _ := router.New()
+	r.Prefix("/slow").GET(func(req *http.Request) types.Responder {
+		close(started)
+		<-release
+		return &testResponder{Status: http.StatusOK, Body: "done"}
+	})
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ln := &closeNotifyListener{Listener: inner, closed: make(chan struct{})}
+	url := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.Serve(ctx, ln)
+	}()
+
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+		}
+		respCh <- resp
+	}()
+
+	// release the handler only once shutdown is under way
+	<-started
+	cancel()
+	<-ln.closed
+	close(release)
+
+	resp := <-respCh
+	if resp == nil {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("want nil error after shutdown, got %v", err)
+	}
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	r, _ := router.New(router.WithShutdownTimeout(10 * time.Millisecond))
+	r.Prefix("/hang").GET(func(req *http.Request) types.Responder {
+		close(started)
+		<-release
+		return &testResponder{Status: http.StatusOK}
+	})
+
+	url, cancel, errCh := serve(t, r)
+
+	go http.Get(url + "/hang")
+
+	<-started
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRunContext_ReturnsListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	r, _ := router.New()
+	if err := r.RunContext(context.Background(), ln.Addr().String()); err == nil {
+		t.Fatal("expected error for address already in use, got nil")
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/elmq0022/kami/tracing"
+)
+
+// WithTracer enables tracing: ServeHTTP starts a server span for every request,
+// continuing the trace propagated in the traceparent and tracestate headers if present.
+// The span is named after the matched route pattern, e.g. "/users/:id", or the method
+// if no route matched, and ends once the response has been written, including after a
+// panic, which is recorded on the span as an error with its "exception.message"; the
+// status of a response aborted after a panic is only recorded if it was sent. Handlers
+// reach the span with tracing.SpanFromContext, start child spans with tracing.Start, and
+// propagate the trace on outgoing requests with tracing.InjectContext.
+func WithTracer(t *tracing.Tracer) Option {
+	return func(r *Router) {
+		r.tracer = t
+	}
+}
+
+// startSpan starts the server span of req, returning the request carrying it.
+func (r *Router) startSpan(req *http.Request) (*http.Request, *tracing.Span) {
+	ctx := req.Context()
+	if sc, ok := tracing.Extract(req.Header); ok {
+		ctx = tracing.ContextWithRemoteParent(ctx, sc)
+	}
+
+	ctx, span := r.tracer.Start(ctx, req.Method, tracing.SpanKindServer)
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("url.path", req.URL.Path)
+	return req.WithContext(ctx), span
+}
+
+// endSpan names span after the matched route and records the response status, and the
+// panic recovered while serving the request, if any. aborted is the panic ServeHTTP is
+// exiting with when the response could not be completed, in which case the status is
+// only recorded if it was sent.
+func endSpan(span *tracing.Span, st *requestState, aborted any) {
+	if st.pattern != "" {
+		span.SetName(st.pattern)
+		span.SetAttribute("http.route", st.pattern)
+	}
+
+	if rec := st.scratch.recovered; rec != nil {
+		span.SetAttribute("exception.message", fmt.Sprint(rec))
+		span.SetError()
+	}
+	if aborted != nil {
+		span.SetError()
+	}
+
+	status := st.scratch.writer.code
+	if status == 0 && aborted == nil {
+		// nothing was written; net/http sends an empty 200
+		status = http.StatusOK
+	}
+	if status != 0 {
+		span.SetAttribute("http.response.status_code", status)
+	}
+	if status >= 500 {
+		span.SetError()
+	}
+
+	span.Finish(st)
+}
@@ -0,0 +1,182 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/responders"
+	"github.com/elmq0022/kami/router"
+	"github.com/elmq0022/kami/tracing"
+	"github.com/elmq0022/kami/types"
+)
+
+func TestWithTracer(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	exp := tracing.NewInMemoryExporter()
+	r, _ := router.New(
+		router.WithTracer(tracing.NewTracer(exp)),
+		router.WithPanicHandler(func(req *http.Request, rec any, stack []byte) types.Responder {
+			return responders.JSONErrorResponse("internal server error", http.StatusInternalServerError)
+		}),
+	)
+
+	var outgoing http.Header
+	r.Prefix("/users/:id").GET(func(req *http.Request) types.Responder {
+		ctx, child := tracing.Start(req.Context(), "load user")
+		defer child.Finish(ctx)
+
+		outgoing = http.Header{}
+		tracing.InjectContext(ctx, outgoing)
+		return responders.JSONResponse("ok", http.StatusOK)
+	})
+	r.Prefix("/panic").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		traceParent string
+		wantName    string
+		wantRoute   any
+		wantStatus  int
+		wantError   bool
+	}{
+		{name: "matched route", path: "/users/42", wantName: "/users/:id", wantRoute: "/users/:id", wantStatus: http.StatusOK},
+		{name: "remote parent", path: "/users/42", traceParent: "00-" + traceID + "-00f067aa0ba902b7-01", wantName: "/users/:id", wantRoute: "/users/:id", wantStatus: http.StatusOK},
+		{name: "not found", path: "/missing", wantName: http.MethodGet, wantStatus: http.StatusNotFound},
+		{name: "panic", path: "/panic", wantName: "/panic", wantRoute: "/panic", wantStatus: http.StatusInternalServerError, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceParent != "" {
+				req.Header.Set(tracing.TraceParentHeader, tt.traceParent)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			spans := exp.Spans()
+			if len(spans) == 0 {
+				t.Fatal("want server span exported")
+			}
+			span := spans[len(spans)-1]
+
+			if span.Kind != tracing.SpanKindServer {
+				t.Errorf("want server span, got kind %v", span.Kind)
+			}
+			if span.Name != tt.wantName {
+				t.Errorf("want span name %q, got %q", tt.wantName, span.Name)
+			}
+			if got := span.Attributes["http.route"]; got != tt.wantRoute {
+				t.Errorf("want http.route %v, got %v", tt.wantRoute, got)
+			}
+			if got := span.Attributes["http.response.status_code"]; got != tt.wantStatus {
+				t.Errorf("want status %d, got %v", tt.wantStatus, got)
+			}
+			if got := span.Attributes["url.path"]; got != tt.path {
+				t.Errorf("want url.path %s, got %v", tt.path, got)
+			}
+			if span.Error != tt.wantError {
+				t.Errorf("want error %v, got %v", tt.wantError, span.Error)
+			}
+
+			if tt.traceParent != "" {
+				if span.SpanContext.TraceID.String() != traceID {
+					t.Errorf("want trace %s continued, got %s", traceID, span.SpanContext.TraceID)
+				}
+				if span.Parent.SpanID.String() != "00f067aa0ba902b7" {
+					t.Errorf("want remote parent span, got %s", span.Parent.SpanID)
+				}
+			}
+
+			if tt.wantRoute == "/users/:id" {
+				if len(spans) != 2 || spans[0].Parent.SpanID != span.SpanContext.SpanID {
+					t.Fatalf("want handler span parented to the server span, got %d spans", len(spans))
+				}
+				if got := outgoing.Get(tracing.TraceParentHeader); got != spans[0].SpanContext.TraceParent() {
+					t.Errorf("want outgoing traceparent of the handler span, got %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithTracer_Disabled(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/").GET(func(req *http.Request) types.Responder {
+		if span := tracing.SpanFromContext(req.Context()); span != nil {
+			t.Error("want no span without WithTracer")
+		}
+		return responders.JSONResponse("ok", http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestWithTracer_Panics(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	r, _ := router.New(
+		router.WithTracer(tracing.NewTracer(exp)),
+		router.WithPanicHandler(func(req *http.Request, rec any, stack []byte) types.Responder {
+			return responders.JSONErrorResponse("internal server error", http.StatusInternalServerError)
+		}),
+	)
+	r.Prefix("/panic").GET(func(req *http.Request) types.Responder {
+		panic("boom")
+	})
+	r.Prefix("/started").GET(func(req *http.Request) types.Responder {
+		return panicResponder{}
+	})
+	r.Prefix("/abort").GET(func(req *http.Request) types.Responder {
+		panic(http.ErrAbortHandler)
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  any
+		wantMessage string
+		wantAbort   bool
+	}{
+		{name: "recovered", path: "/panic", wantStatus: http.StatusInternalServerError, wantMessage: "boom"},
+		{name: "after the response started", path: "/started", wantStatus: http.StatusOK, wantMessage: "boom", wantAbort: true},
+		{name: "aborted by the handler", path: "/abort", wantStatus: nil, wantMessage: http.ErrAbortHandler.Error(), wantAbort: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp.Reset()
+
+			var aborted any
+			func() {
+				defer func() { aborted = recover() }()
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			}()
+			if (aborted == http.ErrAbortHandler) != tt.wantAbort {
+				t.Fatalf("want abort %v, got panic %v", tt.wantAbort, aborted)
+			}
+
+			spans := exp.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("want one span exported, got %d", len(spans))
+			}
+			span := spans[0]
+
+			if !span.Error {
+				t.Error("want span marked as an error")
+			}
+			// an aborted response never defaults to 200
+			if got := span.Attributes["http.response.status_code"]; got != tt.wantStatus {
+				t.Errorf("want status %v, got %v", tt.wantStatus, got)
+			}
+			if got := span.Attributes["exception.message"]; got != tt.wantMessage {
+				t.Errorf("want exception.message %q, got %v", tt.wantMessage, got)
+			}
+		})
+	}
+}
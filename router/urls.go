@@ -0,0 +1,47 @@
+package router
+
+import "fmt"
+
+// Name returns a copy of the router whose next registered route is given the name,
+// so its URL can later be built with URL. The name is not inherited by Prefix.
+// Registering a name twice for different paths panics.
+func (r *Router) Name(name string) *Router {
+	nr := r.shallowCopy()
+	nr.name = name
+	return nr
+}
+
+// URL builds the path of the route registered under name, filling its parameters
+// from key-value pairs, e.g. r.URL("user", "id", "42") for "/users/:id".
+// Values are escaped for use in a URL path; a wildcard value keeps its '/' separators.
+// Returns an error if the name is unknown, a parameter is missing or does not satisfy
+// its constraint, or a key does not match any parameter of the route.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	pattern, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("no route named '%s'", name)
+	}
+
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("odd number of parameter arguments for route '%s'", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	return r.radix.BuildPath(pattern, values)
+}
+
+// registerName records name for the router's current prefix.
+func (r *Router) registerName(name string) {
+	if name == "" {
+		return
+	}
+
+	if existing, ok := r.names[name]; ok && existing != r.prefix {
+		panic(fmt.Sprintf("route name '%s' already registered for path %s", name, existing))
+	}
+	r.names[name] = r.prefix
+}
@@ -0,0 +1,102 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmq0022/kami/router"
+)
+
+func TestRouter_URL(t *testing.T) {
+	r, _ := router.New()
+	api := r.Prefix("/api/v1")
+	api.Prefix("/users").Name("users").GET(testHandler)
+	api.Prefix("/users/:id<int>").Name("user").GET(testHandler)
+	api.Prefix("/users/:id<int>").Name("user").DELETE(testHandler)
+	api.Prefix("/files/:name.{ext}").Name("file").GET(testHandler)
+	r.Prefix("/assets").Name("assets").ServeStatic(nil)
+
+	tests := []struct {
+		name    string
+		route   string
+		params  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "static route", route: "users", want: "/api/v1/users"},
+		{name: "param route", route: "user", params: []string{"id", "42"}, want: "/api/v1/users/42"},
+		{name: "mid-segment params", route: "file", params: []string{"name", "annual report", "ext", "pdf"}, want: "/api/v1/files/annual%20report.pdf"},
+		{name: "wildcard keeps separators", route: "assets", params: []string{"fp", "css/site main.css"}, want: "/assets/css/site%20main.css"},
+		{name: "param value is escaped", route: "file", params: []string{"name", "a/b", "ext", "txt"}, want: "/api/v1/files/a%2Fb.txt"},
+		{name: "unknown route", route: "missing", wantErr: true},
+		{name: "missing param", route: "user", wantErr: true},
+		{name: "constraint not satisfied", route: "user", params: []string{"id", "alice"}, wantErr: true},
+		{name: "unknown param", route: "users", params: []string{"id", "42"}, wantErr: true},
+		{name: "odd params", route: "user", params: []string{"id"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.URL(tt.route, tt.params...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouter_URL_Strict(t *testing.T) {
+	r, _ := router.New(router.WithTrailingSlash(router.TrailingSlashStrict))
+	r.Prefix("/users/").Name("users").GET(testHandler)
+
+	got, err := r.URL("users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/users/" {
+		t.Fatalf("want /users/, got %q", got)
+	}
+
+	// the URL built for a route is served by it
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, got, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status 200 for %s, got %d", got, rr.Code)
+	}
+}
+
+func TestRouter_NameIsNotInherited(t *testing.T) {
+	r, _ := router.New()
+	users := r.Prefix("/users").Name("users")
+	users.GET(testHandler)
+	users.Prefix("/:id").GET(testHandler)
+
+	got, err := r.URL("users")
+	if err != nil || got != "/users" {
+		t.Fatalf("want /users, got %q (%v)", got, err)
+	}
+}
+
+func TestRouter_DuplicateNamePanics(t *testing.T) {
+	r, _ := router.New()
+	r.Prefix("/users").Name("users").GET(testHandler)
+
+	defer func() {
+		if rec := recover(); rec == nil {
+			t.Fatal("expected panic when reusing a route name, got nil")
+		}
+	}()
+
+	r.Prefix("/people").Name("users").GET(testHandler)
+}
@@ -0,0 +1,56 @@
+package router
+
+import "net/http"
+
+// responseWriter records the status code and the number of body bytes written,
+// and whether the response has been started, so that panic recovery knows whether
+// it can still write a status line and body. A Write without a prior WriteHeader
+// is recorded as 200 OK, as net/http sends it.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	code        int
+	bytes       int
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.code = code
+	}
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.code = http.StatusOK
+	}
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher so streaming responders keep working.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.code = http.StatusOK
+		}
+		rw.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for use with http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// status returns the recorded status code, which is 200 OK if nothing was written.
+func (rw *responseWriter) status() int {
+	if rw.code == 0 {
+		return http.StatusOK
+	}
+	return rw.code
+}
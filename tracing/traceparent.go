@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context header names.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// maxTraceStateMembers is the number of list members a tracestate may hold.
+const maxTraceStateMembers = 32
+
+// TraceID identifies a trace. The zero value is invalid.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace. The zero value is invalid.
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether t is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// IsValid reports whether s is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// FlagSampled is the trace flag recording that the caller may have sampled the trace.
+const FlagSampled byte = 0x01
+
+// SpanContext is the part of a span propagated across process boundaries.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	Remote     bool
+}
+
+// IsValid reports whether sc has a valid trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Sampled reports whether the sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// TraceParent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+var errInvalidTraceParent = errors.New("tracing: invalid traceparent")
+
+// ParseTraceParent parses a traceparent header value as defined by W3C Trace Context.
+// Versions above 00 are accepted as long as they start with the version 00 fields.
+func ParseTraceParent(s string) (SpanContext, error) {
+	// version "-" trace-id "-" parent-id "-" flags
+	const size = 2 + 1 + 32 + 1 + 16 + 1 + 2
+
+	if len(s) < size || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, errInvalidTraceParent
+	}
+
+	version, ok := decodeHex(s[:2])
+	if !ok || version[0] == 0xff {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	// version 00 has a fixed size; later versions may append fields
+	if len(s) > size && (version[0] == 0 || s[size] != '-') {
+		return SpanContext{}, errInvalidTraceParent
+	}
+
+	var sc SpanContext
+	traceID, ok := decodeHex(s[3:35])
+	if !ok {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	spanID, ok := decodeHex(s[36:52])
+	if !ok {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	flags, ok := decodeHex(s[53:55])
+	if !ok {
+		return SpanContext{}, errInvalidTraceParent
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	sc.Remote = true
+
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceParent
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex, which is all W3C Trace Context allows.
+func decodeHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// Extract reads the span context propagated in the traceparent and tracestate headers.
+// Returns false if there is no valid traceparent. An invalid tracestate is dropped.
+func Extract(h http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceParent(strings.TrimSpace(h.Get(TraceParentHeader)))
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	// multiple tracestate headers are combined as a single list
+	sc.TraceState = normalizeTraceState(strings.Join(h.Values(TraceStateHeader), ","))
+	return sc, true
+}
+
+// Inject writes sc to the traceparent and tracestate headers, for an outgoing request.
+func Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+
+	h.Set(TraceParentHeader, sc.TraceParent())
+	if sc.TraceState != "" {
+		h.Set(TraceStateHeader, sc.TraceState)
+	} else {
+		h.Del(TraceStateHeader)
+	}
+}
+
+// InjectContext writes the span context of the span in ctx to the traceparent and
+// tracestate headers, for an outgoing request made while handling the span. It does
+// nothing if ctx carries no span.
+func InjectContext(ctx context.Context, h http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		Inject(span.SpanContext, h)
+	}
+}
+
+// normalizeTraceState drops empty list members from a tracestate value, and returns ""
+// if any member is not a key=value pair or there are too many members.
+func normalizeTraceState(s string) string {
+	var members []string
+	for _, m := range strings.Split(s, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(m, "=")
+		if !ok || key == "" || value == "" || strings.ContainsAny(key, " \t") {
+			return ""
+		}
+		members = append(members, m)
+	}
+
+	if len(members) > maxTraceStateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
@@ -0,0 +1,127 @@
+package tracing_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/elmq0022/kami/tracing"
+)
+
+const validTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+		sampled bool
+	}{
+		{name: "valid sampled", value: validTraceParent, sampled: true},
+		{name: "valid not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "future version with extra fields", value: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future", sampled: true},
+		{name: "empty", value: "", wantErr: true},
+		{name: "version 00 with extra fields", value: validTraceParent + "-extra", wantErr: true},
+		{name: "future version without separator", value: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x", wantErr: true},
+		{name: "invalid version ff", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "uppercase hex", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero parent id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{name: "wrong separator", value: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "non hex flags", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := tracing.ParseTraceParent(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := sc.TraceID.String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("want trace id 4bf92f3577b34da6a3ce929d0e0e4736, got %s", got)
+			}
+			if got := sc.SpanID.String(); got != "00f067aa0ba902b7" {
+				t.Errorf("want span id 00f067aa0ba902b7, got %s", got)
+			}
+			if sc.Sampled() != tt.sampled {
+				t.Errorf("want sampled %v, got %v", tt.sampled, sc.Sampled())
+			}
+			if !sc.Remote {
+				t.Error("want parsed span context to be remote")
+			}
+		})
+	}
+}
+
+func TestSpanContext_TraceParent(t *testing.T) {
+	sc, err := tracing.ParseTraceParent(validTraceParent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sc.TraceParent(); got != validTraceParent {
+		t.Errorf("want %s, got %s", validTraceParent, got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name       string
+		traceState []string
+		want       string
+	}{
+		{name: "no tracestate", want: ""},
+		{name: "single header", traceState: []string{"congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"}, want: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+		{name: "multiple headers are combined", traceState: []string{"congo=t61rcWkgMzE", "rojo=00f067aa0ba902b7"}, want: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+		{name: "empty members are dropped", traceState: []string{" congo=t61rcWkgMzE ,, "}, want: "congo=t61rcWkgMzE"},
+		{name: "invalid member drops the tracestate", traceState: []string{"congo=t61rcWkgMzE,invalid"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(tracing.TraceParentHeader, validTraceParent)
+			for _, v := range tt.traceState {
+				h.Add(tracing.TraceStateHeader, v)
+			}
+
+			sc, ok := tracing.Extract(h)
+			if !ok {
+				t.Fatal("want span context to be extracted")
+			}
+			if sc.TraceState != tt.want {
+				t.Errorf("want tracestate %q, got %q", tt.want, sc.TraceState)
+			}
+		})
+	}
+
+	if _, ok := tracing.Extract(http.Header{}); ok {
+		t.Error("want no span context without a traceparent")
+	}
+}
+
+func TestInject(t *testing.T) {
+	sc, _ := tracing.ParseTraceParent(validTraceParent)
+	sc.TraceState = "congo=t61rcWkgMzE"
+
+	h := http.Header{}
+	tracing.Inject(sc, h)
+
+	if got := h.Get(tracing.TraceParentHeader); got != validTraceParent {
+		t.Errorf("want traceparent %s, got %s", validTraceParent, got)
+	}
+	if got := h.Get(tracing.TraceStateHeader); got != "congo=t61rcWkgMzE" {
+		t.Errorf("want tracestate congo=t61rcWkgMzE, got %s", got)
+	}
+
+	h = http.Header{}
+	tracing.Inject(tracing.SpanContext{}, h)
+	if len(h) != 0 {
+		t.Errorf("want no headers for an invalid span context, got %v", h)
+	}
+}
@@ -0,0 +1,172 @@
+// Package tracing provides distributed tracing hooks compatible with OpenTelemetry,
+// without depending on it. Spans are propagated with W3C Trace Context headers and
+// handed to an Exporter when they end, which can bridge them to an OTel SDK or any
+// other backend. InMemoryExporter records spans for tests.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship of a span to its parent and children.
+type SpanKind int
+
+const (
+	// SpanKindInternal is an operation within the service.
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer covers the handling of an incoming request.
+	SpanKindServer
+	// SpanKindClient covers an outgoing request.
+	SpanKindClient
+)
+
+// Exporter receives spans once they have ended. Only sampled spans are exported.
+// ExportSpan is called on the goroutine ending the span and must not block for long.
+type Exporter interface {
+	ExportSpan(ctx context.Context, span *Span)
+}
+
+// Tracer starts spans and hands them to its exporter when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a tracer exporting to exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Span is a timed operation within a trace.
+// Its methods are safe for concurrent use; the fields must not be modified once it has ended.
+type Span struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	Parent      SpanContext
+	Start       time.Time
+	End         time.Time
+	Attributes  map[string]any
+	Error       bool
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SetName renames the span, e.g. once the operation it covers is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttribute records a key-value attribute on the span.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+}
+
+// SetError marks the span as failed.
+func (s *Span) SetError() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Error = true
+	s.mu.Unlock()
+}
+
+// Finish ends the span and exports it if it is sampled. Calls after the first have no effect.
+func (s *Span) Finish(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.tracer != nil && s.tracer.exporter != nil && s.SpanContext.Sampled() {
+		s.tracer.exporter.ExportSpan(ctx, s)
+	}
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span stored in ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteParent returns a copy of ctx carrying a span context received from
+// another process, typically with Extract, to be used as the parent of the next span.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Start begins a span named name, returning it and a copy of ctx carrying it.
+// The parent is the span in ctx, or else the remote parent in ctx; without either the
+// span starts a new, sampled trace. A child span keeps its parent's trace ID, sampling
+// decision and tracestate.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	var parent SpanContext
+	if p := SpanFromContext(ctx); p != nil {
+		parent = p.SpanContext
+	} else if sc, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		parent = sc
+	}
+
+	span := &Span{
+		Name:   name,
+		Kind:   kind,
+		Parent: parent,
+		Start:  time.Now(),
+		tracer: t,
+	}
+
+	if parent.IsValid() {
+		span.SpanContext = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags, TraceState: parent.TraceState}
+	} else {
+		span.SpanContext.Flags = FlagSampled
+		rand.Read(span.SpanContext.TraceID[:])
+	}
+	rand.Read(span.SpanContext.SpanID[:])
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Start begins a child of the span in ctx, using the tracer that started it.
+// Returns ctx unchanged and a nil span if ctx carries no span; a nil span's methods
+// are safe to call, so handlers do not need to check whether tracing is enabled.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, SpanKindInternal)
+}
@@ -0,0 +1,116 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/elmq0022/kami/tracing"
+)
+
+func TestTracer_Start(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exp)
+
+	ctx, root := tracer.Start(context.Background(), "root", tracing.SpanKindServer)
+	if !root.SpanContext.IsValid() || !root.SpanContext.Sampled() {
+		t.Fatalf("want a valid, sampled root span, got %+v", root.SpanContext)
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("want root span without parent, got %+v", root.Parent)
+	}
+	if tracing.SpanFromContext(ctx) != root {
+		t.Fatal("want root span in context")
+	}
+
+	_, child := tracing.Start(ctx, "child")
+	if child.SpanContext.TraceID != root.SpanContext.TraceID {
+		t.Error("want child span in the root span's trace")
+	}
+	if child.Parent.SpanID != root.SpanContext.SpanID {
+		t.Error("want child span parented to the root span")
+	}
+	if child.SpanContext.SpanID == root.SpanContext.SpanID {
+		t.Error("want child span to have its own span id")
+	}
+
+	child.SetAttribute("db.system", "postgresql")
+	child.Finish(ctx)
+	root.Finish(ctx)
+	root.Finish(ctx)
+
+	spans := exp.Spans()
+	if len(spans) != 2 || spans[0] != child || spans[1] != root {
+		t.Fatalf("want child and root exported once in order, got %v", spans)
+	}
+	if spans[0].Attributes["db.system"] != "postgresql" {
+		t.Errorf("want attribute recorded, got %v", spans[0].Attributes)
+	}
+	if spans[1].End.Before(spans[1].Start) {
+		t.Error("want end time after start time")
+	}
+
+	exp.Reset()
+	if len(exp.Spans()) != 0 {
+		t.Error("want no spans after Reset")
+	}
+}
+
+func TestTracer_Start_RemoteParent(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      string
+		wantExport bool
+	}{
+		{name: "sampled", flags: "01", wantExport: true},
+		{name: "not sampled", flags: "00", wantExport: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(tracing.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-"+tt.flags)
+			h.Set(tracing.TraceStateHeader, "congo=t61rcWkgMzE")
+			remote, _ := tracing.Extract(h)
+
+			exp := tracing.NewInMemoryExporter()
+			ctx := tracing.ContextWithRemoteParent(context.Background(), remote)
+			ctx, span := tracing.NewTracer(exp).Start(ctx, "server", tracing.SpanKindServer)
+
+			if span.SpanContext.TraceID != remote.TraceID {
+				t.Error("want span to continue the remote trace")
+			}
+			if span.Parent != remote {
+				t.Errorf("want remote parent %+v, got %+v", remote, span.Parent)
+			}
+
+			out := http.Header{}
+			tracing.InjectContext(ctx, out)
+			if got, want := out.Get(tracing.TraceParentHeader), span.SpanContext.TraceParent(); got != want {
+				t.Errorf("want propagated traceparent %s, got %s", want, got)
+			}
+			if got := out.Get(tracing.TraceStateHeader); got != "congo=t61rcWkgMzE" {
+				t.Errorf("want tracestate propagated, got %q", got)
+			}
+
+			span.Finish(ctx)
+			if got := len(exp.Spans()) == 1; got != tt.wantExport {
+				t.Errorf("want exported %v, got %v", tt.wantExport, got)
+			}
+		})
+	}
+}
+
+func TestStart_WithoutSpan(t *testing.T) {
+	ctx := context.Background()
+	got, span := tracing.Start(ctx, "child")
+	if span != nil || got != ctx {
+		t.Fatal("want no span without a parent span in context")
+	}
+
+	// a nil span is safe to use
+	span.SetName("child")
+	span.SetAttribute("key", "value")
+	span.SetError()
+	span.Finish(ctx)
+}
@@ -0,0 +1,25 @@
+package types
+
+import "context"
+
+type contextKey struct {
+	name string
+}
+
+// RequestIDContextKey is the context key under which the request ID is stored, as a
+// string. Use WithRequestID and RequestID to set and read it; the key is exported so
+// that contexts managed elsewhere, such as the router's request state, can answer for it.
+var RequestIDContextKey = &contextKey{"request-id"}
+
+// WithRequestID returns a copy of ctx carrying the correlation ID of the request.
+// router.RequestIDMiddleware stores the ID it accepts or generates under the same key,
+// so that packages the router depends on, such as handlers, can read it too.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDContextKey, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "" if none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDContextKey).(string)
+	return id
+}
@@ -0,0 +1,37 @@
+package types
+
+import "net/http"
+
+// StatusError is implemented by errors that know the HTTP status code they
+// should be reported with.
+type StatusError interface {
+	error
+	StatusCode() int
+}
+
+// HTTPError is an error carrying an HTTP status code and a client-facing message.
+// Err optionally records the underlying cause, which is available via errors.Is and
+// errors.As but is never sent to the client.
+type HTTPError struct {
+	Status int
+	Msg    string
+	Err    error
+}
+
+// Error returns the message, falling back to the status text.
+func (e *HTTPError) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return http.StatusText(e.Status)
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// Unwrap returns the underlying cause, if any.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,31 @@
+package types
+
+// Param is a single URL parameter captured from the request path.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params holds the URL parameters captured for a request in the order they appear in the route.
+// It is a slice rather than a map so the router can reuse its backing array across requests.
+type Params []Param
+
+// Get returns the value of the named parameter and whether it was present,
+// mirroring the two-value map index expression.
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Map returns the parameters as a newly allocated map.
+func (ps Params) Map() map[string]string {
+	m := make(map[string]string, len(ps))
+	for _, p := range ps {
+		m[p.Key] = p.Value
+	}
+	return m
+}
@@ -32,8 +32,37 @@ type Handler func(req *http.Request) Responder
 type Routes []Route
 
 // Route represents a single HTTP route mapping.
+// Path is the pattern the route was registered with, such as "/users/:id".
+// Name is the optional name given with router.Router.Name, and Middleware is the
+// number of middleware wrapped around the handler at registration.
+// Meta holds the optional documentation given with router.Router.Describe.
 type Route struct {
-	Method  string
-	Path    string
-	Handler Handler
+	Method     string     `json:"method"`
+	Path       string     `json:"path"`
+	Name       string     `json:"name,omitempty"`
+	Middleware int        `json:"middleware"`
+	Meta       *RouteMeta `json:"-"`
+	Handler    Handler    `json:"-"`
 }
+
+// RouteMeta documents a route for generated API descriptions such as OpenAPI.
+// Request and Response are example values of the Go types decoded from the request
+// body and encoded into the response body, e.g. CreateUser{} or []User{}; leave them
+// nil when the route has no body. Status is the success status, defaulting to 200 OK.
+type RouteMeta struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     any
+	Response    any
+	Status      int
+}
+
+// ErrorHandler is a Handler variant that may fail. A non-nil error is converted
+// into a response by the router's error mapping instead of a Responder being built
+// at every failure point. See router.Router.HandleErr.
+type ErrorHandler func(req *http.Request) (Responder, error)
+
+// PanicHandler builds the response for a request whose handler or responder panicked.
+// It receives the value passed to panic and the stack trace of the panicking goroutine.
+type PanicHandler func(req *http.Request, recovered any, stack []byte) Responder